@@ -0,0 +1,210 @@
+// Command deadletter liệt kê và gửi lại các submission nằm trong dead-letter stream
+// (nats.jetstream.deadLetterStream).
+//
+//	deadletter list                 liệt kê các message
+//	deadletter show <seq>           in payload gốc của một message
+//	deadletter requeue <seq>...     gửi lại payload lên subject gốc và xóa khỏi dead-letter stream
+//	deadletter requeue -all         gửi lại tất cả
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	appConfig "github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const requestTimeout = 30 * time.Second
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [-config dir] <list | show <seq> | requeue [-all] <seq>...>\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	configDir := flag.String("config", "", "thư mục chứa config.yaml (mặc định: ./configs, ., /etc/runner-service/)")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var configPaths []string
+	if *configDir != "" {
+		configPaths = append(configPaths, *configDir)
+	}
+	cfg, err := appConfig.LoadConfig(configPaths...)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.NATS.JetStream.DeadLetterStream == "" {
+		log.Fatalf("nats.jetstream.deadLetterStream is not configured")
+	}
+
+	nc, err := nats.Connect(cfg.NATS.URL)
+	if err != nil {
+		log.Fatalf("Error connecting to NATS: %v", err)
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		log.Fatalf("Failed to create JetStream context: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	stream, err := js.Stream(ctx, cfg.NATS.JetStream.DeadLetterStream)
+	if err != nil {
+		log.Fatalf("Failed to open dead-letter stream %s: %v", cfg.NATS.JetStream.DeadLetterStream, err)
+	}
+
+	args := flag.Args()
+	switch args[0] {
+	case "list":
+		err = list(ctx, stream)
+	case "show":
+		if len(args) != 2 {
+			usage()
+			os.Exit(2)
+		}
+		err = show(ctx, stream, args[1])
+	case "requeue":
+		err = requeue(ctx, js, nc, stream, args[1:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// deadLetterMsg là một message trong dead-letter stream.
+type deadLetterMsg struct {
+	seq  uint64
+	time time.Time
+	dl   models.DeadLetter
+}
+
+// messages đọc tất cả message còn lại trong stream, theo thứ tự sequence.
+func messages(ctx context.Context, stream jetstream.Stream) ([]deadLetterMsg, error) {
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream info: %w", err)
+	}
+	var msgs []deadLetterMsg
+	if info.State.Msgs == 0 {
+		return msgs, nil
+	}
+	for seq := info.State.FirstSeq; seq <= info.State.LastSeq; seq++ {
+		msg, err := get(ctx, stream, seq)
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			continue // Đã bị xóa (ví dụ đã requeue)
+		}
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, *msg)
+	}
+	return msgs, nil
+}
+
+func get(ctx context.Context, stream jetstream.Stream, seq uint64) (*deadLetterMsg, error) {
+	raw, err := stream.GetMsg(ctx, seq)
+	if err != nil {
+		return nil, err
+	}
+	msg := &deadLetterMsg{seq: raw.Sequence, time: raw.Time}
+	if err := json.Unmarshal(raw.Data, &msg.dl); err != nil {
+		return nil, fmt.Errorf("message %d is not a dead letter: %w", seq, err)
+	}
+	return msg, nil
+}
+
+func list(ctx context.Context, stream jetstream.Stream) error {
+	msgs, err := messages(ctx, stream)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SEQ\tTIME\tSTAGE\tSUBMISSION\tDELIVERIES\tREASON")
+	for _, m := range msgs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\n",
+			m.seq, m.time.Local().Format(time.DateTime), m.dl.Stage, m.dl.SubmissionID, m.dl.Deliveries, m.dl.Reason)
+	}
+	return w.Flush()
+}
+
+func show(ctx context.Context, stream jetstream.Stream, arg string) error {
+	seq, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid sequence %q", arg)
+	}
+	msg, err := get(ctx, stream, seq)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Seq:        %d\nTime:       %s\nStage:      %s\nSubmission: %s\nSubject:    %s\nDeliveries: %d\nReason:     %s\n\n",
+		msg.seq, msg.time.Local().Format(time.DateTime), msg.dl.Stage, msg.dl.SubmissionID, msg.dl.Subject, msg.dl.Deliveries, msg.dl.Reason)
+	_, err = os.Stdout.Write(append(msg.dl.Payload, '\n'))
+	return err
+}
+
+func requeue(ctx context.Context, js jetstream.JetStream, nc *nats.Conn, stream jetstream.Stream, args []string) error {
+	fs := flag.NewFlagSet("requeue", flag.ExitOnError)
+	all := fs.Bool("all", false, "gửi lại tất cả message trong dead-letter stream")
+	fs.Parse(args)
+
+	var msgs []deadLetterMsg
+	if *all {
+		var err error
+		if msgs, err = messages(ctx, stream); err != nil {
+			return err
+		}
+	} else {
+		if fs.NArg() == 0 {
+			return fmt.Errorf("requeue: no sequence given (use -all to requeue everything)")
+		}
+		for _, arg := range fs.Args() {
+			seq, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid sequence %q", arg)
+			}
+			msg, err := get(ctx, stream, seq)
+			if err != nil {
+				return fmt.Errorf("message %d: %w", seq, err)
+			}
+			msgs = append(msgs, *msg)
+		}
+	}
+
+	for _, m := range msgs {
+		// Qua JetStream nếu subject gốc thuộc một stream (runner dùng JetStream), nếu không thì core NATS.
+		_, err := js.Publish(ctx, m.dl.Subject, m.dl.Payload)
+		if errors.Is(err, nats.ErrNoResponders) || errors.Is(err, jetstream.ErrNoStreamResponse) {
+			if err = nc.Publish(m.dl.Subject, m.dl.Payload); err == nil {
+				err = nc.FlushTimeout(requestTimeout)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to requeue message %d: %w", m.seq, err)
+		}
+		if err := stream.DeleteMsg(ctx, m.seq); err != nil {
+			return fmt.Errorf("message %d was requeued but could not be deleted: %w", m.seq, err)
+		}
+		fmt.Printf("Requeued message %d (SubmissionID %q) to %s\n", m.seq, m.dl.SubmissionID, m.dl.Subject)
+	}
+	return nil
+}
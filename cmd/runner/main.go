@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"github.com/Mirai3103/remote-compiler/internal/core"
 	"github.com/Mirai3103/remote-compiler/internal/core/sandbox"
+	"github.com/Mirai3103/remote-compiler/internal/grpcapi"
+	"github.com/Mirai3103/remote-compiler/internal/httpapi"
+	"github.com/Mirai3103/remote-compiler/internal/language"
 	"log"
 	"os"
 	"os/signal"
@@ -46,33 +50,109 @@ func main() {
 	}
 	defer nc.Close()
 	log.Printf("Connected to NATS server: %s", natsURL)
-	sandboxExecutor := sandbox.NewExecutor(cfg.Runner)
-	if sandboxExecutor == nil {
-		panic("Failed to create sandbox executor")
+	sandboxExecutor, err := sandbox.NewExecutor(cfg.Runner)
+	if err != nil {
+		log.Fatalf("Failed to create sandbox executor (sandboxType=%q): %v", cfg.Runner.SandboxType, err)
+	}
+	log.Printf("Using sandbox executor: %s", sandboxExecutor.ID())
+
+	languages, err := language.NewRegistry(cfg.Languages)
+	if err != nil {
+		log.Fatalf("Invalid language configuration: %v", err)
+	}
+	if languages.Len() == 0 {
+		log.Printf("Warning: no languages configured, every submission will be rejected.")
 	}
+	log.Printf("Supported languages: %v", languages.IDs())
 
-	publisher := natsClient.NewPublisher(nc)
-	runner := core.NewRunner(sandboxExecutor, publisher, &cfg.Runner)
+	publisher := natsClient.NewPublisher(nc, cfg.NATS)
+	runner := core.NewRunner(sandboxExecutor, publisher, &cfg.Runner, languages)
 
 	jobHandler := worker.NewJobHandler(publisher, runner, &cfg.Runner) // jobHandler là *worker.JobHandler
 
+	// Drain chạy sau khi đã ngừng nhận submission (defer chạy theo thứ tự ngược).
+	defer func() {
+		// Consider nc.Drain() for graceful shutdown of NATS connection
+		if err := nc.Drain(); err != nil {
+			log.Printf("Error draining NATS connection: %v", err)
+		}
+	}()
+
 	// Khi gọi NewSubscriber, jobHandler (*worker.JobHandler)
 	// tương thích với natsClient.SubmissionProcessor interface
-	// vì nó có method HandleSubmission(models.Submission)
-	subscriber := natsClient.NewSubscriber(nc, jobHandler)
-	subSubscription, err := subscriber.SubscribeToSubmissions()
+	// vì nó có method HandleSubmission(models.Submission, time.Time)
+	subscriber := natsClient.NewSubscriber(nc, jobHandler, publisher, cfg.NATS)
+	cancelSubscription, err := subscriber.SubscribeToCancellations(jobHandler)
 	if err != nil {
-		log.Fatalf("Error setting up NATS subscription: %v", err)
+		log.Fatalf("Error setting up NATS cancel subscription: %v", err)
 	}
 	defer func() {
-		if err := subSubscription.Unsubscribe(); err != nil {
+		if err := cancelSubscription.Unsubscribe(); err != nil {
 			log.Printf("Error unsubscribing: %v", err)
 		}
-		// Consider nc.Drain() for graceful shutdown of NATS connection
-		if err := nc.Drain(); err != nil {
-			log.Printf("Error draining NATS connection: %v", err)
+	}()
+	// Request-reply cho nút "Run code"; luôn qua core NATS vì người gửi chờ reply.
+	runSubscription, err := subscriber.SubscribeToRunRequests(jobHandler)
+	if err != nil {
+		log.Fatalf("Error setting up NATS run subscription: %v", err)
+	}
+	defer func() {
+		if err := runSubscription.Unsubscribe(); err != nil {
+			log.Printf("Error unsubscribing: %v", err)
 		}
 	}()
+	// Chỉ nhận tối đa maxConcurrentJobs submission cùng lúc; phần còn lại để cho runner khác.
+	var intake *natsClient.Intake
+	if js := cfg.NATS.JetStream; js.Enabled {
+		// Durable pull consumer: submission đang chạy khi runner crash sẽ được giao lại cho runner khác.
+		intake, err = subscriber.ConsumeJetStream(context.Background(), js, cfg.Runner.MaxConcurrentJobs)
+		if err != nil {
+			log.Fatalf("Error setting up JetStream consumer: %v", err)
+		}
+	} else {
+		// Không dùng JetStream cho submission nhưng vẫn lưu dead-letter vào stream để cmd/deadletter đọc được.
+		if err := subscriber.EnsureDeadLetterStream(context.Background(), js); err != nil {
+			log.Printf("Dead letters will not be stored: %v", err)
+		}
+		intake, err = subscriber.SubscribeToSubmissions(cfg.Runner.MaxConcurrentJobs)
+		if err != nil {
+			log.Fatalf("Error setting up NATS subscription: %v", err)
+		}
+	}
+	defer func() {
+		log.Printf("Waiting for running submissions to finish (%+v)...", intake.Stats())
+		intake.Stop()
+	}()
+	if interval := time.Duration(cfg.Runner.StatsLogIntervalSec) * time.Second; interval > 0 {
+		go logStats(interval, intake, jobHandler)
+	}
+
+	if cfg.HTTP.Enabled {
+		// HTTP API dùng chung jobHandler, nên chung giới hạn maxConcurrentJobs với submission từ NATS.
+		apiServer := httpapi.NewServer(cfg.HTTP, jobHandler, publisher, intake)
+		if err := apiServer.Start(); err != nil {
+			log.Fatalf("Error starting HTTP API on %s: %v", cfg.HTTP.Addr, err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := apiServer.Shutdown(ctx); err != nil {
+				log.Printf("Error shutting down HTTP API: %v", err)
+			}
+		}()
+	}
+
+	if cfg.GRPC.Enabled {
+		grpcServer := grpcapi.NewServer(cfg.GRPC, jobHandler, publisher, cfg.Languages)
+		if err := grpcServer.Start(); err != nil {
+			log.Fatalf("Error starting gRPC API on %s: %v", cfg.GRPC.Addr, err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			grpcServer.Shutdown(ctx)
+		}()
+	}
 
 	log.Println("Runner Service is now listening for submissions on NATS.")
 
@@ -82,3 +162,12 @@ func main() {
 
 	log.Println("Shutting down Runner Service...")
 }
+
+// logStats ghi log định kỳ trạng thái nhận submission và các job đang chạy/chờ slot.
+func logStats(interval time.Duration, intake *natsClient.Intake, jobHandler *worker.JobHandler) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		log.Printf("Stats: intake %+v, jobs %+v", intake.Stats(), jobHandler.Stats())
+	}
+}
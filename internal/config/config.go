@@ -13,18 +13,93 @@ type Config struct {
 	NATS              NATSConfig   `mapstructure:"nats"`
 	Runner            RunnerConfig `mapstructure:"runner"`
 	MaxConcurrentJobs int          `mapstructure:"maxConcurrentJobs"` // Số job xử lý đồng thời tối đa (sẽ cần semaphore)
+	// Languages là danh sách ngôn ngữ runner hỗ trợ. Submission chỉ gửi language ID,
+	// lệnh biên dịch/chạy luôn lấy từ đây chứ không lấy từ client.
+	Languages []LanguageConfig `mapstructure:"languages"`
+	// HTTP là HTTP/JSON API tùy chọn cho client không dùng NATS.
+	HTTP HTTPConfig `mapstructure:"http"`
+	// GRPC là gRPC API tùy chọn (service Judge trong assets/judge.proto).
+	GRPC GRPCConfig `mapstructure:"grpc"`
 	// Thêm các mục config khác ở đây, ví dụ: LogConfig
 }
 
+// LanguageConfig mô tả cách biên dịch và chạy một ngôn ngữ. Cú pháp lệnh (quoting, "&&" để
+// biên dịch nhiều bước) và danh sách placeholder được mô tả ở language.Template.
+type LanguageConfig struct {
+	ID             string `mapstructure:"id"`
+	Name           string `mapstructure:"name"`
+	SourceFile     string `mapstructure:"sourceFile"`     // Tên file source, ví dụ "main.cpp"
+	BinaryFile     string `mapstructure:"binaryFile"`     // Tên file output của trình biên dịch, ví dụ "main"
+	CompileCommand string `mapstructure:"compileCommand"` // Rỗng với ngôn ngữ thông dịch
+	RunCommand     string `mapstructure:"runCommand"`
+	// AllowedFlags là các flag biên dịch mà submission được phép chọn (ví dụ "-O2", "-std=c++17").
+	// Flag được chèn vào vị trí {flags} của CompileCommand; flag không có trong danh sách sẽ bị từ chối.
+	AllowedFlags []string `mapstructure:"allowedFlags"`
+}
+
+// HTTPConfig chứa cấu hình cho HTTP/JSON API (internal/httpapi).
+type HTTPConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Addr    string `mapstructure:"addr"` // Địa chỉ lắng nghe, ví dụ ":8080"
+	// SyncMaxTestCases: submission có tối đa chừng này test case được chấm đồng bộ (kết quả trong response),
+	// lớn hơn thì chấm bất đồng bộ và trả về submission ID. Client có thể chọn bằng ?mode=sync|async.
+	SyncMaxTestCases int `mapstructure:"syncMaxTestCases"`
+	ResultTTLSec     int `mapstructure:"resultTtlSec"` // Thời gian giữ kết quả của submission bất đồng bộ sau khi xong
+	MaxBodyMb        int `mapstructure:"maxBodyMb"`    // Kích thước tối đa của request body
+	// MaxPendingAsync giới hạn số submission bất đồng bộ chưa xong (đang chờ slot hoặc đang chạy);
+	// vượt quá thì request mới bị từ chối với 503.
+	MaxPendingAsync int `mapstructure:"maxPendingAsync"`
+}
+
+// GRPCConfig chứa cấu hình cho gRPC API (internal/grpcapi).
+type GRPCConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Addr    string `mapstructure:"addr"` // Địa chỉ lắng nghe, ví dụ ":9090"
+}
+
 // NATSConfig chứa cấu hình kết nối NATS
 type NATSConfig struct {
 	URL                   string `mapstructure:"url"`
 	SubmissionCreatedSubj string `mapstructure:"submissionCreatedSubject"`
 	SubmissionResultSubj  string `mapstructure:"submissionResultSubject"`
+	// SubmissionSummarySubj là subject của message tổng kết gửi một lần khi submission xử lý xong.
+	SubmissionSummarySubj string `mapstructure:"submissionSummarySubject"`
 	QueueGroup            string `mapstructure:"queueGroup"`
+	// DeadLetterSubj nhận các message không xử lý được (không decode được, bị từ chối, giao quá nhiều lần).
+	DeadLetterSubj string `mapstructure:"deadLetterSubject"`
+	// SubmissionScoreSubj và SubmissionProgressSubjPrefix là subject của điểm subtask và của sự kiện tiến độ
+	// (subject thực tế là "<prefix>.<submissionId>").
+	SubmissionScoreSubj          string `mapstructure:"submissionScoreSubject"`
+	SubmissionProgressSubjPrefix string `mapstructure:"submissionProgressSubjectPrefix"`
+	// SubjectPrefix, nếu có, được thêm vào trước mọi subject (ví dụ "staging" -> "staging.submission.created"),
+	// để nhiều môi trường dùng chung một NATS cluster.
+	SubjectPrefix string `mapstructure:"subjectPrefix"`
+	// SubmissionCancelSubj nhận yêu cầu hủy submission; mọi runner đều subscribe (không dùng queue group).
+	SubmissionCancelSubj string `mapstructure:"submissionCancelSubject"`
+	// SubmissionRunSubj nhận request chạy code đồng bộ (request-reply); kết quả được trả trong reply.
+	SubmissionRunSubj string `mapstructure:"submissionRunSubject"`
 	// Các cài đặt nâng cao khác cho NATS client nếu cần
 	// MaxReconnects int `mapstructure:"maxReconnects"`
 	// ReconnectWaitSec int `mapstructure:"reconnectWaitSec"`
+
+	// JetStream, khi được bật, thay QueueSubscribe của core NATS bằng durable pull consumer.
+	JetStream JetStreamConfig `mapstructure:"jetstream"`
+}
+
+// JetStreamConfig chứa cấu hình nhận submission qua JetStream. Message chỉ được ack sau khi
+// kết quả cuối cùng đã được publish, nên submission đang chạy khi runner crash sẽ được giao lại.
+type JetStreamConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	Stream             string `mapstructure:"stream"`             // Stream chứa subject submission.created; được tạo nếu chưa có
+	Durable            string `mapstructure:"durable"`            // Tên durable consumer, dùng chung giữa các runner
+	AckWaitSec         int    `mapstructure:"ackWaitSec"`         // Thời gian chờ ack trước khi message được giao lại
+	MaxDeliver         int    `mapstructure:"maxDeliver"`         // Số lần giao tối đa của một message
+	InProgressEverySec int    `mapstructure:"inProgressEverySec"` // Chu kỳ gửi InProgress cho job đang chạy; 0 = ackWaitSec/3
+	// DeadLetterStream lưu các message trên DeadLetterSubj để xem lại và gửi lại; được tạo nếu chưa có.
+	DeadLetterStream string `mapstructure:"deadLetterStream"`
+	// MaxAckPending giới hạn số message chưa ack của cả durable consumer, tức của mọi runner cộng lại;
+	// 0 = mặc định của server. Mỗi runner tự giới hạn ở runner.maxConcurrentJobs.
+	MaxAckPending int `mapstructure:"maxAckPending"`
 }
 
 // RunnerConfig chứa cấu hình cho hoạt động của runner
@@ -32,9 +107,102 @@ type RunnerConfig struct {
 	SandboxBaseDir        string `mapstructure:"sandboxBaseDir"`        // Thư mục gốc cho các sandbox tạm thời
 	CompilationTimeoutSec int    `mapstructure:"compilationTimeoutSec"` // Thời gian timeout cho bước biên dịch (giây)
 	MaxConcurrentJobs     int    `mapstructure:"maxConcurrentJobs"`     // Số job xử lý đồng thời tối đa (sẽ cần semaphore)
+	// CompilationMemoryLimitKb là giới hạn bộ nhớ cho trình biên dịch (KB)
+	CompilationMemoryLimitKb int `mapstructure:"compilationMemoryLimitKb"`
+	// CompileLimits ghi đè giới hạn biên dịch theo language ID (ví dụ: java).
+	// Lưu ý: viper chuyển key của map về chữ thường.
+	CompileLimits map[string]CompileLimitConfig `mapstructure:"compileLimits"`
 	// DefaultTimeLimitMs int `mapstructure:"defaultTimeLimitMs"` // Nếu muốn có giá trị mặc định
 	// DefaultMemoryLimitKb int `mapstructure:"defaultMemoryLimitKb"`// Nếu muốn có giá trị mặc định
-	SandboxType string `mapstructure:"sandboxType"` // Loại sandbox (Docker, Firejail, ...); có thể dùng để chọn runner
+	SandboxType string         `mapstructure:"sandboxType"` // Loại sandbox (Docker, Firejail, ...); có thể dùng để chọn runner
+	Nsjail      NsjailConfig   `mapstructure:"nsjail"`      // Cấu hình riêng cho sandboxType "nsjail"
+	Isolate     IsolateConfig  `mapstructure:"isolate"`     // Cấu hình riêng cho sandboxType "isolate"
+	Firejail    FirejailConfig `mapstructure:"firejail"`    // Cấu hình riêng cho sandboxType "firejail"
+	// CompileCache lưu binary đã biên dịch để bỏ qua bước biên dịch khi chấm lại cùng source code.
+	CompileCache CompileCacheConfig `mapstructure:"compileCache"`
+	// Checker chứa giới hạn khi chạy checker (special judge) và các checker đã đăng ký sẵn.
+	Checker CheckerConfig `mapstructure:"checker"`
+	// StatsLogIntervalSec là chu kỳ ghi log trạng thái hàng đợi và worker (giây); 0 = tắt.
+	StatsLogIntervalSec int `mapstructure:"statsLogIntervalSec"`
+}
+
+// CheckerConfig chứa cấu hình cho checker (special judge) và interactor kiểu testlib.
+type CheckerConfig struct {
+	TimeLimitMs   int                       `mapstructure:"timeLimitMs"`   // Giới hạn thời gian cho mỗi lần chạy checker
+	MemoryLimitKb int                       `mapstructure:"memoryLimitKb"` // Giới hạn bộ nhớ cho checker (KB)
+	Registered    []RegisteredCheckerConfig `mapstructure:"registered"`    // Checker có sẵn, submission tham chiếu qua ID
+}
+
+// RegisteredCheckerConfig là một checker đã được biên dịch sẵn trên host.
+type RegisteredCheckerConfig struct {
+	ID   string `mapstructure:"id"`
+	Path string `mapstructure:"path"` // Đường dẫn tới binary của checker; được copy vào thư mục của checker trước khi chạy
+}
+
+// CompileCacheConfig chứa cấu hình cho cache binary đã biên dịch (core/cache.CompileCache).
+type CompileCacheConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Dir       string `mapstructure:"dir"`       // Thư mục chứa binary đã cache, nên nằm ngoài sandboxBaseDir
+	MaxSizeMb int    `mapstructure:"maxSizeMb"` // Tổng dung lượng tối đa; vượt quá thì xóa binary ít dùng nhất (LRU)
+}
+
+// CompileLimitConfig chứa giới hạn biên dịch riêng cho một ngôn ngữ; giá trị 0 = dùng giá trị chung.
+type CompileLimitConfig struct {
+	TimeoutSec    int `mapstructure:"timeoutSec"`
+	MemoryLimitKb int `mapstructure:"memoryLimitKb"`
+}
+
+// FirejailConfig chứa cấu hình cho executor dùng firejail.
+type FirejailConfig struct {
+	FirejailPath string `mapstructure:"firejailPath"` // Đường dẫn tới binary firejail
+	MaxProcesses int    `mapstructure:"maxProcesses"` // pids.max của cgroup riêng cho mỗi lần chạy; < 0 = không giới hạn
+	FsizeKb      int    `mapstructure:"fsizeKb"`      // --rlimit-fsize (KB)
+	// CgroupParent là cgroup v2 (runner phải ghi được) chứa cgroup riêng của mỗi lần chạy.
+	// Không dùng --rlimit-nproc vì nó đếm mọi process của user, kể cả runner và các sandbox khác.
+	CgroupParent string `mapstructure:"cgroupParent"`
+	// RlimitAsFactor nhân với memory limit để ra --rlimit-as. Address space luôn lớn hơn RSS
+	// (đặc biệt với Go, JVM), nên giới hạn chính vẫn là việc đo RSS như direct executor.
+	RlimitAsFactor   float64  `mapstructure:"rlimitAsFactor"`
+	ExtraTimeSeconds float64  `mapstructure:"extraTimeSeconds"` // Cộng thêm vào --timeout của firejail
+	ExtraArgs        []string `mapstructure:"extraArgs"`        // Tham số firejail bổ sung, ví dụ "--seccomp"
+}
+
+// IsolateConfig chứa cấu hình cho executor dùng isolate (sandbox.IsolateExecutor).
+type IsolateConfig struct {
+	IsolatePath      string  `mapstructure:"isolatePath"`
+	EnvPath          string  `mapstructure:"envPath"`
+	DefaultFsizeKb   int     `mapstructure:"defaultFsizeKb"`
+	DefaultProcesses int     `mapstructure:"defaultProcesses"`
+	ExtraTimeSeconds float64 `mapstructure:"extraTimeSeconds"`
+	WallTimeFactor   float64 `mapstructure:"wallTimeFactor"`
+	// TempDir is a directory on the host for temporary files like stdin, stdout, meta.
+	// If empty, os.TempDir() will be used.
+	TempDir string `mapstructure:"tempDir"`
+	// BoxIDMin/BoxIDMax is the range of box IDs shared by all runner instances on the host.
+	BoxIDMin int `mapstructure:"boxIdMin"`
+	BoxIDMax int `mapstructure:"boxIdMax"`
+	// InstanceIndex/InstanceCount partition the box ID range between runner instances,
+	// e.g. with 4 instances over 0-999 instance 1 owns boxes 250-499.
+	InstanceIndex int `mapstructure:"instanceIndex"`
+	InstanceCount int `mapstructure:"instanceCount"`
+}
+
+// NsjailConfig chứa cấu hình cho executor dùng nsjail.
+type NsjailConfig struct {
+	NsjailPath string `mapstructure:"nsjailPath"` // Đường dẫn tới binary nsjail
+	// ChrootDir, nếu khác rỗng, được bind-mount read-only làm "/" của jail (ví dụ: "/alpine").
+	ChrootDir string `mapstructure:"chrootDir"`
+	// ReadOnlyMounts là danh sách thư mục host được bind-mount read-only vào jail (cùng đường dẫn),
+	// ví dụ "/usr/local/go" hoặc "/usr/local/cargo".
+	ReadOnlyMounts   []string `mapstructure:"readOnlyMounts"`
+	EnvPath          string   `mapstructure:"envPath"`          // Biến PATH bên trong jail
+	MaxProcesses     int      `mapstructure:"maxProcesses"`     // cgroup pids.max
+	FsizeKb          int      `mapstructure:"fsizeKb"`          // Giới hạn kích thước file ghi ra (KB)
+	ExtraTimeSeconds float64  `mapstructure:"extraTimeSeconds"` // Thời gian cộng thêm vào wall-time limit
+	WallTimeFactor   float64  `mapstructure:"wallTimeFactor"`   // Hệ số nhân time limit để ra wall-time limit
+	// TempDir là thư mục trên host để chứa config, stdin, stats và log của nsjail.
+	// Nếu rỗng sẽ dùng os.TempDir().
+	TempDir string `mapstructure:"tempDir"`
 }
 
 // AppConfig là biến toàn cục (hoặc được truyền đi) để giữ config đã load.
@@ -80,12 +248,63 @@ func LoadConfig(configPaths ...string) (*Config, error) {
 	v.SetDefault("nats.url", "nats://localhost:4222")
 	v.SetDefault("nats.submissionCreatedSubject", "submission.created")
 	v.SetDefault("nats.submissionResultSubject", "submission.executed")
+	v.SetDefault("nats.submissionSummarySubject", "submission.finished")
 	// v.SetDefault("nats.maxReconnects", 5)
 	v.SetDefault("nats.queueGroup", "runner-service-group")
+	v.SetDefault("nats.jetstream.enabled", false)
+	v.SetDefault("nats.jetstream.stream", "SUBMISSIONS")
+	v.SetDefault("nats.jetstream.durable", "runner")
+	v.SetDefault("nats.jetstream.ackWaitSec", 60)
+	v.SetDefault("nats.jetstream.maxDeliver", 5)
+	v.SetDefault("nats.jetstream.deadLetterStream", "SUBMISSIONS_DLQ")
+	v.SetDefault("nats.jetstream.maxAckPending", 0)
+	v.SetDefault("nats.deadLetterSubject", "submission.deadletter")
+	v.SetDefault("nats.submissionScoreSubject", "submission.scored")
+	v.SetDefault("nats.submissionProgressSubjectPrefix", "submission.progress")
+	v.SetDefault("nats.subjectPrefix", "")
+	v.SetDefault("nats.submissionCancelSubject", "submission.cancel")
+	v.SetDefault("nats.submissionRunSubject", "submission.run")
+	v.SetDefault("http.enabled", false)
+	v.SetDefault("http.addr", ":8080")
+	v.SetDefault("http.syncMaxTestCases", 5)
+	v.SetDefault("http.resultTtlSec", 3600)
+	v.SetDefault("http.maxBodyMb", 64)
+	v.SetDefault("http.maxPendingAsync", 100)
+	v.SetDefault("grpc.enabled", false)
+	v.SetDefault("grpc.addr", ":9090")
 	v.SetDefault("runner.sandboxBaseDir", "/tmp/runner_sandbox")
 	v.SetDefault("runner.compilationTimeoutSec", 30)
 	v.SetDefault("runner.sandboxType", "direct") // Hoặc "firejail", "docker", ...
 	v.SetDefault("runner.maxConcurrentJobs", 100)
+	v.SetDefault("runner.statsLogIntervalSec", 60)
+	v.SetDefault("runner.compilationMemoryLimitKb", 1024*1024) // 1 GB
+	v.SetDefault("runner.nsjail.nsjailPath", "nsjail")
+	v.SetDefault("runner.nsjail.envPath", "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin")
+	v.SetDefault("runner.nsjail.maxProcesses", 64)
+	v.SetDefault("runner.nsjail.fsizeKb", 65536)
+	v.SetDefault("runner.nsjail.extraTimeSeconds", 1.0)
+	v.SetDefault("runner.nsjail.wallTimeFactor", 2.0)
+	v.SetDefault("runner.isolate.isolatePath", "isolate")
+	v.SetDefault("runner.isolate.envPath", "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin")
+	v.SetDefault("runner.isolate.defaultFsizeKb", 65536)
+	v.SetDefault("runner.isolate.defaultProcesses", 64)
+	v.SetDefault("runner.isolate.extraTimeSeconds", 2.0)
+	v.SetDefault("runner.isolate.wallTimeFactor", 2.0)
+	v.SetDefault("runner.isolate.boxIdMin", 0)
+	v.SetDefault("runner.isolate.boxIdMax", 999)
+	v.SetDefault("runner.isolate.instanceIndex", 0)
+	v.SetDefault("runner.isolate.instanceCount", 1)
+	v.SetDefault("runner.firejail.firejailPath", "firejail")
+	v.SetDefault("runner.firejail.maxProcesses", 64)
+	v.SetDefault("runner.firejail.cgroupParent", "/sys/fs/cgroup/remote-compiler-firejail")
+	v.SetDefault("runner.firejail.fsizeKb", 65536)
+	v.SetDefault("runner.firejail.rlimitAsFactor", 4.0)
+	v.SetDefault("runner.firejail.extraTimeSeconds", 1.0)
+	v.SetDefault("runner.compileCache.enabled", false)
+	v.SetDefault("runner.compileCache.dir", "/tmp/runner_compile_cache")
+	v.SetDefault("runner.compileCache.maxSizeMb", 1024)
+	v.SetDefault("runner.checker.timeLimitMs", 10000)
+	v.SetDefault("runner.checker.memoryLimitKb", 512*1024) // 512 MB
 
 	// 8. Đọc file config
 	if err := v.ReadInConfig(); err != nil {
@@ -0,0 +1,228 @@
+// Package cache implements a content-addressed cache for compiled submissions.
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CompileCache stores compiled binaries in a local directory, keyed by Key(),
+// and evicts the least recently used entries once the directory grows over maxBytes.
+// Each entry is a single file named after its key; its modification time records
+// the last use, so the LRU order survives restarts.
+type CompileCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List               // Front = most recently used
+	entries map[string]*list.Element // key -> element holding *cacheEntry
+	size    int64                    // Total size of all entries (bytes)
+}
+
+type cacheEntry struct {
+	key  string
+	size int64
+}
+
+// New opens (or creates) a cache in dir and indexes the entries already on disk.
+func New(dir string, maxBytes int64) (*CompileCache, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("compile cache size must be positive, got %d", maxBytes)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create compile cache directory %s: %w", dir, err)
+	}
+	c := &CompileCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compile cache directory %s: %w", dir, err)
+	}
+	type diskEntry struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	var onDisk []diskEntry
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue // ".tmp-*" files are unfinished writes
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		onDisk = append(onDisk, diskEntry{key: f.Name(), size: info.Size(), modTime: info.ModTime()})
+	}
+	// Oldest first, so PushFront leaves the most recently used entry at the front.
+	sort.Slice(onDisk, func(i, j int) bool { return onDisk[i].modTime.Before(onDisk[j].modTime) })
+	for _, e := range onDisk {
+		c.entries[e.key] = c.lru.PushFront(&cacheEntry{key: e.key, size: e.size})
+		c.size += e.size
+	}
+	c.mu.Lock()
+	c.evictLocked()
+	c.mu.Unlock()
+
+	log.Printf("[compile-cache] Opened %s with %d entries (%d/%d bytes)", dir, c.lru.Len(), c.size, c.maxBytes)
+	return c, nil
+}
+
+// Key returns the cache key for a compilation. The compile command must be the
+// template (before placeholders are replaced), since rendered commands contain
+// per-submission paths.
+func Key(languageID, compileCommand, toolchainVersion, source string) string {
+	h := sha256.New()
+	for _, part := range []string{languageID, compileCommand, toolchainVersion, source} {
+		// Length prefix so that ("ab", "c") and ("a", "bc") hash differently.
+		fmt.Fprintf(h, "%d:%s\n", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ToolchainVersion identifies the executables used to compile by their path, size and
+// modification time, so upgrading any part of the toolchain invalidates old entries
+// without having to run it. The paths must already be resolved the way the sandbox
+// resolves them (see sandbox.Executor.ResolveExecutable); a path that cannot be
+// stat'ed is identified by the path alone.
+func ToolchainVersion(executables ...string) string {
+	parts := make([]string, 0, len(executables))
+	for _, path := range executables {
+		info, err := os.Stat(path)
+		if err != nil {
+			parts = append(parts, path)
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano()))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Get copies the cached binary for key to destPath. It returns false if there is no entry.
+func (c *CompileCache) Get(key, destPath string) (bool, error) {
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	entryPath := filepath.Join(c.dir, key)
+	if err := copyFile(entryPath, destPath, 0755); err != nil {
+		if os.IsNotExist(err) {
+			// Removed behind our back; forget it.
+			c.remove(key)
+			return false, nil
+		}
+		return false, err
+	}
+	now := time.Now()
+	_ = os.Chtimes(entryPath, now, now) // Record the use for the LRU order after a restart
+	return true, nil
+}
+
+// Put stores the binary at srcPath under key, evicting old entries if needed.
+func (c *CompileCache) Put(key, srcPath string) error {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	if info.Size() > c.maxBytes {
+		return fmt.Errorf("binary of %d bytes is larger than the whole compile cache", info.Size())
+	}
+
+	// Write to a temp file first so a concurrent Get never sees a partial binary.
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	if err := copyFile(srcPath, tmpPath, 0755); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(c.dir, key)); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		c.size -= entry.size
+		entry.size = info.Size()
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, size: info.Size()})
+	}
+	c.size += info.Size()
+	c.evictLocked()
+	return nil
+}
+
+// remove drops an entry from the index.
+func (c *CompileCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.size -= elem.Value.(*cacheEntry).size
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// evictLocked removes least recently used entries until the cache fits in maxBytes.
+func (c *CompileCache) evictLocked() {
+	for c.size > c.maxBytes {
+		elem := c.lru.Back()
+		if elem == nil {
+			return
+		}
+		entry := elem.Value.(*cacheEntry)
+		if err := os.Remove(filepath.Join(c.dir, entry.key)); err != nil && !os.IsNotExist(err) {
+			log.Printf("[compile-cache] Failed to evict %s: %v", entry.key, err)
+		}
+		c.size -= entry.size
+		c.lru.Remove(elem)
+		delete(c.entries, entry.key)
+	}
+}
+
+// copyFile copies src to dst, creating or truncating dst with the given mode.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
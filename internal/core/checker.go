@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Mirai3103/remote-compiler/internal/core/sandbox"
+	"github.com/Mirai3103/remote-compiler/internal/language"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// Exit code của checker theo quy ước testlib.
+const (
+	checkerExitOK                = 0
+	checkerExitWrongAnswer       = 1
+	checkerExitPresentationError = 2
+	checkerExitFail              = 3 // Checker tự báo lỗi (ví dụ đáp án của đề sai)
+)
+
+const (
+	checkerBinaryName       = "checker" // Tên file khi copy checker đã đăng ký vào thư mục của checker
+	checkerInputFile        = "input.txt"
+	checkerOutputFile       = "output.txt"
+	checkerAnswerFile       = "answer.txt"
+	checkerMessageLimit     = 4 * 1024
+	defaultCheckerTimeLimit = 10 * time.Second // Dùng khi runner.checker.timeLimitMs không được đặt
+	defaultCheckerMemoryKb  = 512 * 1024       // Dùng khi runner.checker.memoryLimitKb không được đặt
+)
+
+// checkerSpec là checker của submission sau khi đã kiểm tra: hoặc binary đã đăng ký, hoặc source cần biên dịch.
+type checkerSpec struct {
+	path string            // Binary của checker đã đăng ký trên host
+	lang language.Language // Ngôn ngữ của checker gửi kèm submission
+	code string
+}
+
+// preparedChecker là checker đã sẵn sàng chạy, nằm trong thư mục riêng (ngoài thư mục của user code,
+// để chương trình của thí sinh không sửa được checker hay đáp án).
+type preparedChecker struct {
+	dir           string
+	command       []string // argv của checker, chưa có 3 file input/output/answer
+	timeLimitMs   int
+	memoryLimitKb int
+}
+
+// writeFiles ghi input, output của thí sinh và đáp án của test case vào thư mục của checker.
+func (c *preparedChecker) writeFiles(tc models.TestCase, output string) (inputPath, outputPath, answerPath string, err error) {
+	inputPath = filepath.Join(c.dir, checkerInputFile)
+	outputPath = filepath.Join(c.dir, checkerOutputFile)
+	answerPath = filepath.Join(c.dir, checkerAnswerFile)
+	for path, content := range map[string]string{inputPath: tc.Input, outputPath: output, answerPath: tc.ExpectOutput} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return "", "", "", err
+		}
+	}
+	return inputPath, outputPath, answerPath, nil
+}
+
+// resolveChecker kiểm tra checker của submission. Trả về nil nếu submission không dùng checker.
+func (r *Runner) resolveChecker(spec *models.Checker) (*checkerSpec, error) {
+	if spec == nil {
+		return nil, nil
+	}
+	if spec.ID != "" {
+		for _, registered := range r.runnerConfig.Checker.Registered {
+			if registered.ID == spec.ID {
+				return &checkerSpec{path: registered.Path}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown checker %q", spec.ID)
+	}
+	if spec.Code == "" {
+		return nil, fmt.Errorf("checker must have either an id or code")
+	}
+	lang, err := r.languages.Resolve(spec.LanguageID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("checker: %w", err)
+	}
+	return &checkerSpec{lang: lang, code: spec.Code}, nil
+}
+
+// newCheckerDir tạo thư mục mới cho checker của submission có thư mục tempDir, cạnh tempDir nhưng không nằm
+// trong đó (tempDir được mount read-write vào sandbox của user code). Tên thư mục là tên của tempDir nối với
+// pattern bắt đầu bằng "."; submission ID không chứa ".", nên không submission nào có thư mục trùng với nó.
+func newCheckerDir(tempDir, pattern string) (string, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(tempDir), filepath.Base(tempDir)+pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create checker directory: %w", err)
+	}
+	// MkdirTemp tạo thư mục 0700; sandbox có thể chạy checker bằng user khác.
+	if err := os.Chmod(dir, 0755); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to create checker directory: %w", err)
+	}
+	return dir, nil
+}
+
+// prepareChecker chuẩn bị checker trong dir: copy binary đã đăng ký, hoặc ghi source và biên dịch một lần
+// cho cả submission. Lỗi biên dịch checker được trả về dưới dạng error (lỗi của đề, không phải của thí sinh).
+func (r *Runner) prepareChecker(ctx context.Context, submissionID string, spec *checkerSpec, dir string) (*preparedChecker, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create checker directory: %w", err)
+	}
+	c := &preparedChecker{
+		dir:           dir,
+		timeLimitMs:   r.runnerConfig.Checker.TimeLimitMs,
+		memoryLimitKb: r.runnerConfig.Checker.MemoryLimitKb,
+	}
+	if c.timeLimitMs <= 0 {
+		c.timeLimitMs = int(defaultCheckerTimeLimit.Milliseconds())
+	}
+	if c.memoryLimitKb <= 0 {
+		c.memoryLimitKb = defaultCheckerMemoryKb
+	}
+
+	if spec.path != "" {
+		// Copy vào thư mục của checker để sandbox nào cũng thấy được binary.
+		data, err := os.ReadFile(spec.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read registered checker: %w", err)
+		}
+		binaryPath := filepath.Join(dir, checkerBinaryName)
+		if err := os.WriteFile(binaryPath, data, 0755); err != nil {
+			return nil, fmt.Errorf("failed to copy registered checker: %w", err)
+		}
+		c.command = []string{binaryPath}
+		return c, nil
+	}
+
+	vars := language.Vars{
+		SourceFile:    filepath.Join(dir, spec.lang.SourceFile),
+		TempDir:       dir,
+		MemoryLimitKb: c.memoryLimitKb,
+		TimeLimitMs:   c.timeLimitMs,
+	}
+	if err := os.WriteFile(vars.SourceFile, []byte(spec.code), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write checker source: %w", err)
+	}
+	if spec.lang.IsCompiled() {
+		vars.OutputFile = filepath.Join(dir, spec.lang.BinaryFile)
+		compileResult, _, err := r.compileCached(ctx, submissionID, spec.lang, spec.code, vars)
+		if err != nil {
+			return nil, fmt.Errorf("checker compilation failed: %w", err)
+		}
+		if compileResult.Status != models.Success {
+			return nil, fmt.Errorf("checker compilation failed (%s): %s", compileResult.Status, compileResult.Output)
+		}
+	}
+	c.command = spec.lang.RunArgs(vars)
+	return c, nil
+}
+
+// check chạy checker cho một test case và trả về verdict cùng thông báo của checker.
+func (r *Runner) check(ctx context.Context, c *preparedChecker, submissionID string, tc models.TestCase, output string) (models.TestcaseStatus, string) {
+	inputPath, outputPath, answerPath, err := c.writeFiles(tc, output)
+	if err != nil {
+		return models.InternalError, fmt.Sprintf("Failed to write checker files: %v", err)
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, time.Duration(c.timeLimitMs)*time.Millisecond+compileTimeoutGrace)
+	defer cancel()
+	command := append(append([]string{}, c.command...), inputPath, outputPath, answerPath)
+	result, err := r.sandboxExecutor.Execute(checkCtx, sandbox.RunRequest{
+		SubmissionID:     submissionID,
+		TestCaseID:       tc.ID + "-checker",
+		RunCommand:       command,
+		WorkingDirectory: c.dir,
+		TimeLimitMs:      c.timeLimitMs,
+		MemoryLimitKb:    c.memoryLimitKb,
+	})
+	if err != nil {
+		log.Printf("Sandbox error while running checker for TestCaseID %s, SubmissionID %s: %v", tc.ID, submissionID, err)
+		return models.InternalError, fmt.Sprintf("Checker execution failed: %v", err)
+	}
+
+	return checkerVerdict(result)
+}
+
+// checkerVerdict chuyển kết quả chạy checker/interactor thành verdict theo exit code testlib.
+// Checker lỗi (exit code 3, TLE, crash...) được báo là InternalError.
+func checkerVerdict(result *sandbox.ExecuteResult) (models.TestcaseStatus, string) {
+	// testlib ghi thông báo ra stderr; một số checker tự viết thì ghi ra stdout.
+	message := strings.TrimSpace(result.Stderr)
+	if message == "" {
+		message = strings.TrimSpace(result.Stdout)
+	}
+	if len(message) > checkerMessageLimit {
+		message = message[:checkerMessageLimit] + "..."
+	}
+
+	switch {
+	case result.Status == models.Success:
+		return models.Success, message
+	case result.Status != models.RuntimeError:
+		// Checker bị TLE/MLE: lỗi của đề, không phải của thí sinh.
+		return models.InternalError, fmt.Sprintf("Checker failed with status %s. %s", result.Status, message)
+	}
+	switch result.ExitCode {
+	case checkerExitOK:
+		return models.Success, message
+	case checkerExitWrongAnswer:
+		return models.WrongAnswer, message
+	case checkerExitPresentationError:
+		return models.PresentationError, message
+	case checkerExitFail:
+		return models.InternalError, "Checker reported failure: " + message
+	default:
+		return models.InternalError, fmt.Sprintf("Checker exited with unexpected code %d: %s", result.ExitCode, message)
+	}
+}
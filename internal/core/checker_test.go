@@ -0,0 +1,42 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// TestNewCheckerDir kiểm tra thư mục checker không nằm trong thư mục của user code và không trùng với thư mục
+// của submission nào khác (ví dụ submission "abc-checker" so với checker của submission "abc").
+func TestNewCheckerDir(t *testing.T) {
+	base := t.TempDir()
+	tempDir := filepath.Join(base, "abc")
+	dir, err := newCheckerDir(tempDir, ".checker-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(dir) != base || strings.HasPrefix(dir, tempDir+string(filepath.Separator)) {
+		t.Errorf("checker dir %s is not a sibling of %s", dir, tempDir)
+	}
+	if err := models.ValidateSubmissionID(filepath.Base(dir)); err == nil {
+		t.Errorf("checker dir name %q is a valid submission ID", filepath.Base(dir))
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("checker dir mode = %v, want 0755", info.Mode().Perm())
+	}
+
+	other, err := newCheckerDir(tempDir, ".checker-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == dir {
+		t.Errorf("two checker dirs of the same submission share %s", dir)
+	}
+}
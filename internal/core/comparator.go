@@ -0,0 +1,224 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// Các chế độ so sánh output, chọn theo SubmissionSettings.ComparisonMode.
+const (
+	ComparisonExact              = "exact"                       // So sánh chính xác từng byte
+	ComparisonLines              = "lines"                       // Bỏ qua khoảng trắng cuối mỗi dòng và dòng trống ở cuối (mặc định)
+	ComparisonTokens             = "tokens"                      // So sánh từng token, không phân biệt loại/số lượng khoảng trắng
+	ComparisonTrailingBlankLines = "ignore_trailing_blank_lines" // Chỉ bỏ qua dòng trống ở cuối output
+	ComparisonNumeric            = "numeric"                     // Như "tokens", nhưng số thực được so sánh với sai số cho phép
+)
+
+// defaultEpsilon được dùng cho chế độ "numeric" khi submission không đặt sai số nào.
+const defaultEpsilon = 1e-6
+
+// CompareResult là kết quả so sánh output của một test case.
+type CompareResult struct {
+	Equal   bool
+	Message string // Mô tả chỗ khác nhau đầu tiên khi Equal = false
+}
+
+// Comparator so sánh output thực tế của chương trình với output mong đợi.
+type Comparator interface {
+	Compare(actual, expected string) CompareResult
+}
+
+// NewComparator tạo Comparator cho submission từ settings. Khi ComparisonMode rỗng,
+// WithWhitespace = true chọn chế độ "tokens", nếu không thì dùng "lines".
+// WithTrim và WithCaseSensitive áp dụng cho mọi chế độ.
+func NewComparator(settings models.SubmissionSettings) (Comparator, error) {
+	mode := settings.ComparisonMode
+	if mode == "" {
+		mode = ComparisonLines
+		if settings.WithWhitespace {
+			mode = ComparisonTokens
+		}
+	}
+
+	var c Comparator
+	switch mode {
+	case ComparisonExact:
+		c = exactComparator{}
+	case ComparisonLines:
+		c = lineComparator{trimLine: true}
+	case ComparisonTokens:
+		c = tokenComparator{}
+	case ComparisonTrailingBlankLines:
+		c = lineComparator{}
+	case ComparisonNumeric:
+		absEps, relEps := settings.AbsoluteEpsilon, settings.RelativeEpsilon
+		if absEps < 0 || relEps < 0 {
+			return nil, fmt.Errorf("epsilon must not be negative (absoluteEpsilon=%g, relativeEpsilon=%g)", absEps, relEps)
+		}
+		if absEps == 0 && relEps == 0 {
+			absEps, relEps = defaultEpsilon, defaultEpsilon
+		}
+		c = numericComparator{absEps: absEps, relEps: relEps}
+	default:
+		return nil, fmt.Errorf("unknown comparison mode %q", mode)
+	}
+	if settings.WithTrim || !settings.WithCaseSensitive {
+		c = normalizingComparator{inner: c, trim: settings.WithTrim, ignoreCase: !settings.WithCaseSensitive}
+	}
+	return c, nil
+}
+
+// normalizingComparator trim và/hoặc chuyển về chữ thường trước khi so sánh.
+type normalizingComparator struct {
+	inner      Comparator
+	trim       bool
+	ignoreCase bool
+}
+
+func (c normalizingComparator) Compare(actual, expected string) CompareResult {
+	if c.trim {
+		actual = strings.TrimSpace(actual)
+		expected = strings.TrimSpace(expected)
+	}
+	if c.ignoreCase {
+		actual = strings.ToLower(actual)
+		expected = strings.ToLower(expected)
+	}
+	return c.inner.Compare(actual, expected)
+}
+
+type exactComparator struct{}
+
+func (exactComparator) Compare(actual, expected string) CompareResult {
+	if actual == expected {
+		return CompareResult{Equal: true}
+	}
+	// Tìm byte khác nhau đầu tiên để báo vị trí.
+	i := 0
+	for i < len(actual) && i < len(expected) && actual[i] == expected[i] {
+		i++
+	}
+	return CompareResult{Message: fmt.Sprintf("output differs at byte %d", i)}
+}
+
+// lineComparator so sánh từng dòng, bỏ qua dòng trống ở cuối và "\r" của dòng kiểu Windows.
+// Với trimLine, khoảng trắng ở cuối mỗi dòng cũng được bỏ qua.
+type lineComparator struct {
+	trimLine bool
+}
+
+func (c lineComparator) Compare(actual, expected string) CompareResult {
+	actualLines := c.lines(actual)
+	expectedLines := c.lines(expected)
+	for i := 0; i < len(actualLines) && i < len(expectedLines); i++ {
+		if actualLines[i] != expectedLines[i] {
+			return CompareResult{Message: fmt.Sprintf("line %d differs: expected %q, got %q",
+				i+1, truncateForMessage(expectedLines[i]), truncateForMessage(actualLines[i]))}
+		}
+	}
+	if len(actualLines) != len(expectedLines) {
+		return CompareResult{Message: fmt.Sprintf("expected %d lines, got %d", len(expectedLines), len(actualLines))}
+	}
+	return CompareResult{Equal: true}
+}
+
+func (c lineComparator) lines(s string) []string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if c.trimLine {
+			line = strings.TrimRight(line, " \t\r")
+		}
+		lines[i] = line
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// tokenComparator so sánh dãy token được tách bởi khoảng trắng bất kỳ (kể cả xuống dòng).
+type tokenComparator struct{}
+
+func (tokenComparator) Compare(actual, expected string) CompareResult {
+	actualTokens := strings.Fields(actual)
+	expectedTokens := strings.Fields(expected)
+	for i := 0; i < len(actualTokens) && i < len(expectedTokens); i++ {
+		if actualTokens[i] != expectedTokens[i] {
+			return CompareResult{Message: fmt.Sprintf("token %d differs: expected %q, got %q",
+				i+1, truncateForMessage(expectedTokens[i]), truncateForMessage(actualTokens[i]))}
+		}
+	}
+	if len(actualTokens) != len(expectedTokens) {
+		return CompareResult{Message: fmt.Sprintf("expected %d tokens, got %d", len(expectedTokens), len(actualTokens))}
+	}
+	return CompareResult{Equal: true}
+}
+
+// numericComparator so sánh từng token; token nào trong expected là số thì token tương ứng
+// trong actual phải là số và lệch không quá absEps hoặc relEps*|expected|. Token khác so sánh chính xác.
+type numericComparator struct {
+	absEps float64
+	relEps float64
+}
+
+func (c numericComparator) Compare(actual, expected string) CompareResult {
+	actualTokens := strings.Fields(actual)
+	expectedTokens := strings.Fields(expected)
+	for i := 0; i < len(actualTokens) && i < len(expectedTokens); i++ {
+		exp, act := expectedTokens[i], actualTokens[i]
+		expValue, expIsNumber := parseNumber(exp)
+		if !expIsNumber {
+			if act != exp {
+				return CompareResult{Message: fmt.Sprintf("token %d differs: expected %q, got %q",
+					i+1, truncateForMessage(exp), truncateForMessage(act))}
+			}
+			continue
+		}
+		actValue, actIsNumber := parseNumber(act)
+		if !actIsNumber {
+			return CompareResult{Message: fmt.Sprintf("token %d: expected number %s, got %q",
+				i+1, truncateForMessage(exp), truncateForMessage(act))}
+		}
+		diff := math.Abs(actValue - expValue)
+		if diff > c.absEps && diff > c.relEps*math.Abs(expValue) {
+			return CompareResult{Message: fmt.Sprintf("token %d differs: expected %s, got %s (difference %g)",
+				i+1, truncateForMessage(exp), truncateForMessage(act), diff)}
+		}
+	}
+	if len(actualTokens) != len(expectedTokens) {
+		return CompareResult{Message: fmt.Sprintf("expected %d tokens, got %d", len(expectedTokens), len(actualTokens))}
+	}
+	return CompareResult{Equal: true}
+}
+
+// parseNumber chỉ chấp nhận số thập phân thông thường (ví dụ "-1.5", "3e-7"),
+// không nhận "inf", "nan" hay số hex mà strconv.ParseFloat vẫn chấp nhận.
+func parseNumber(token string) (float64, bool) {
+	if token == "" || strings.ContainsAny(token, "xXpP_") {
+		return 0, false
+	}
+	switch c := token[0]; {
+	case c >= '0' && c <= '9', c == '-', c == '+', c == '.':
+	default:
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(token, 64)
+	if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, false
+	}
+	return v, true
+}
+
+// truncateForMessage giữ cho message ngắn khi dòng/token rất dài.
+func truncateForMessage(s string) string {
+	const maxLen = 64
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
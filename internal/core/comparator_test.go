@@ -0,0 +1,209 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+func TestComparator(t *testing.T) {
+	exact := models.SubmissionSettings{ComparisonMode: ComparisonExact, WithCaseSensitive: true}
+	lines := models.SubmissionSettings{ComparisonMode: ComparisonLines, WithCaseSensitive: true}
+	tokens := models.SubmissionSettings{ComparisonMode: ComparisonTokens, WithCaseSensitive: true}
+	blank := models.SubmissionSettings{ComparisonMode: ComparisonTrailingBlankLines, WithCaseSensitive: true}
+	numeric := models.SubmissionSettings{ComparisonMode: ComparisonNumeric, WithCaseSensitive: true}
+
+	with := func(s models.SubmissionSettings, f func(*models.SubmissionSettings)) models.SubmissionSettings {
+		f(&s)
+		return s
+	}
+	trim := func(s *models.SubmissionSettings) { s.WithTrim = true }
+	ignoreCase := func(s *models.SubmissionSettings) { s.WithCaseSensitive = false }
+	eps := func(abs, rel float64) func(*models.SubmissionSettings) {
+		return func(s *models.SubmissionSettings) { s.AbsoluteEpsilon, s.RelativeEpsilon = abs, rel }
+	}
+
+	tests := []struct {
+		name     string
+		settings models.SubmissionSettings
+		actual   string
+		expected string
+		equal    bool
+		message  string // Phần message mong đợi khi equal = false
+	}{
+		{"exact/equal", exact, "1 2\n", "1 2\n", true, ""},
+		{"exact/trailing newline", exact, "1 2", "1 2\n", false, "output differs at byte 3"},
+		{"exact/trailing space", exact, "1 2 \n", "1 2\n", false, "output differs at byte 3"},
+		{"exact/crlf", exact, "1\r\n", "1\n", false, "output differs at byte 1"},
+		{"exact/trim", with(exact, trim), "  1 2\n\n", "1 2", true, ""},
+		{"exact/case", exact, "YES", "yes", false, "output differs at byte 0"},
+		{"exact/ignore case", with(exact, ignoreCase), "YES", "yes", true, ""},
+
+		{"lines/equal", lines, "a\nb\n", "a\nb\n", true, ""},
+		{"lines/trailing spaces", lines, "a  \nb\t\n", "a\nb\n", true, ""},
+		{"lines/crlf", lines, "a\r\nb\r\n", "a\nb\n", true, ""},
+		{"lines/trailing blank lines", lines, "a\nb\n\n\n", "a\nb", true, ""},
+		{"lines/leading spaces", lines, " a\nb\n", "a\nb\n", false, `line 1 differs: expected "a", got " a"`},
+		{"lines/inner spaces", lines, "a  b\n", "a b\n", false, "line 1 differs"},
+		{"lines/missing line", lines, "a\n", "a\nb\n", false, "expected 2 lines, got 1"},
+		{"lines/blank line in middle", lines, "a\n\nb\n", "a\nb\n", false, "line 2 differs"},
+		{"lines/trim", with(lines, trim), "\n  a\nb\n", "a\nb", true, ""},
+		{"lines/case", lines, "Yes\n", "yes\n", false, "line 1 differs"},
+		{"lines/ignore case", with(lines, ignoreCase), "Yes\n", "yes\n", true, ""},
+
+		{"tokens/equal", tokens, "1 2 3\n", "1 2 3\n", true, ""},
+		{"tokens/any whitespace", tokens, " 1\t2\r\n\n3  ", "1 2 3\n", true, ""},
+		{"tokens/differs", tokens, "1 2 4", "1 2 3", false, `token 3 differs: expected "3", got "4"`},
+		{"tokens/extra token", tokens, "1 2 3 4", "1 2 3", false, "expected 3 tokens, got 4"},
+		{"tokens/case", tokens, "YES", "yes", false, "token 1 differs"},
+		{"tokens/ignore case", with(tokens, ignoreCase), "YES", "yes", true, ""},
+		{"tokens/trim", with(tokens, trim), "  1 2  ", "1 2", true, ""},
+		{"tokens/default with whitespace", models.SubmissionSettings{WithWhitespace: true, WithCaseSensitive: true}, "1\n2", "1 2", true, ""},
+		{"default is lines", models.SubmissionSettings{WithCaseSensitive: true}, "1\n2", "1 2", false, "line 1 differs"},
+
+		{"blank/trailing blank lines", blank, "a\nb\n\n\n", "a\nb\n", true, ""},
+		{"blank/crlf", blank, "a\r\nb\r\n\r\n", "a\nb", true, ""},
+		{"blank/trailing spaces", blank, "a \nb\n", "a\nb\n", false, `line 1 differs: expected "a", got "a "`},
+		{"blank/trim", with(blank, trim), "a\nb  \n", "a\nb\n", true, ""},
+		{"blank/trim keeps inner lines", with(blank, trim), "a \nb\n", "a\nb\n", false, "line 1 differs"},
+		{"blank/ignore case", with(blank, ignoreCase), "A\nB\n", "a\nb\n", true, ""},
+
+		{"numeric/equal", numeric, "1.5 2", "1.5 2", true, ""},
+		{"numeric/default epsilon", numeric, "0.3000001", "0.3", true, ""},
+		{"numeric/beyond default epsilon", numeric, "0.30001", "0.3", false, "token 1 differs: expected 0.3, got 0.30001 (difference"},
+		{"numeric/absolute bound", with(numeric, eps(1e-3, 0)), "1.001", "1", true, ""},
+		{"numeric/beyond absolute bound", with(numeric, eps(1e-3, 0)), "1.0011", "1", false, "token 1 differs"},
+		{"numeric/relative bound", with(numeric, eps(0, 1e-3)), "1001", "1000", true, ""},
+		{"numeric/beyond relative bound", with(numeric, eps(0, 1e-3)), "1001.1", "1000", false, "token 1 differs"},
+		{"numeric/either bound", with(numeric, eps(1e-3, 1e-9)), "1000.0009", "1000", true, ""},
+		{"numeric/exponent", numeric, "3e-7", "0.0000003", true, ""},
+		{"numeric/integers", numeric, "-0 +5", "0 5", true, ""},
+		{"numeric/words exact", numeric, "YES 1.0", "YES 1", true, ""},
+		{"numeric/word differs", numeric, "NO 1", "YES 1", false, `token 1 differs: expected "YES", got "NO"`},
+		{"numeric/not a number", numeric, "abc", "1.5", false, `token 1: expected number 1.5, got "abc"`},
+		{"numeric/inf rejected", numeric, "inf", "1e308", false, `token 1: expected number 1e308, got "inf"`},
+		{"numeric/nan rejected", numeric, "nan", "0", false, `token 1: expected number 0, got "nan"`},
+		{"numeric/hex rejected", numeric, "0x10", "16", false, `token 1: expected number 16, got "0x10"`},
+		{"numeric/expected inf compared as word", numeric, "inf", "inf", true, ""},
+		{"numeric/missing token", numeric, "1", "1 2", false, "expected 2 tokens, got 1"},
+		{"numeric/ignore case", with(numeric, ignoreCase), "Yes 1E-9", "yes 0", true, ""},
+		{"numeric/trim", with(numeric, trim), "  1 \n", "1", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewComparator(tt.settings)
+			if err != nil {
+				t.Fatalf("NewComparator: %v", err)
+			}
+			got := c.Compare(tt.actual, tt.expected)
+			if got.Equal != tt.equal {
+				t.Fatalf("Compare(%q, %q).Equal = %v, want %v (message %q)", tt.actual, tt.expected, got.Equal, tt.equal, got.Message)
+			}
+			if !tt.equal && !strings.Contains(got.Message, tt.message) {
+				t.Errorf("Compare(%q, %q).Message = %q, want it to contain %q", tt.actual, tt.expected, got.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestNewComparatorErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings models.SubmissionSettings
+	}{
+		{"unknown mode", models.SubmissionSettings{ComparisonMode: "fuzzy"}},
+		{"negative absolute epsilon", models.SubmissionSettings{ComparisonMode: ComparisonNumeric, AbsoluteEpsilon: -1}},
+		{"negative relative epsilon", models.SubmissionSettings{ComparisonMode: ComparisonNumeric, RelativeEpsilon: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewComparator(tt.settings); err == nil {
+				t.Errorf("NewComparator(%+v) returned no error", tt.settings)
+			}
+		})
+	}
+}
+
+func TestTruncateForMessage(t *testing.T) {
+	c, err := NewComparator(models.SubmissionSettings{ComparisonMode: ComparisonTokens, WithCaseSensitive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("x", 100)
+	got := c.Compare(long, "y").Message
+	if !strings.Contains(got, strings.Repeat("x", 64)+"...") || strings.Contains(got, strings.Repeat("x", 65)) {
+		t.Errorf("message %q does not truncate the token to 64 bytes", got)
+	}
+}
+
+// legacyCompare là cách so sánh trước khi có comparator: so sánh chính xác sau khi trim và xử lý case.
+func legacyCompare(actual, expected string, settings models.SubmissionSettings) bool {
+	if settings.WithTrim {
+		actual = strings.TrimSpace(actual)
+		expected = strings.TrimSpace(expected)
+	}
+	if !settings.WithCaseSensitive {
+		actual = strings.ToLower(actual)
+		expected = strings.ToLower(expected)
+	}
+	return actual == expected
+}
+
+// TestDefaultModeKeepsLegacyBehaviour kiểm tra submission không đặt comparisonMode: output được chấp nhận
+// trước đây vẫn được chấp nhận, output khác nội dung vẫn bị Wrong Answer, chỉ khác biệt về khoảng trắng
+// cuối dòng / dòng trống cuối / "\r\n" là được chấp nhận thêm. "exact" giữ đúng cách so sánh cũ.
+func TestDefaultModeKeepsLegacyBehaviour(t *testing.T) {
+	outputs := []string{
+		"", "\n", "1 2\n", "1 2", "1 2 \n", "1  2\n", " 1 2\n", "1\r\n2\r\n", "1\n2\n", "1\n2\n\n", "1\n\n2\n",
+		"YES\n", "yes\n", "Yes", "3\n", "1 3\n",
+	}
+	for _, settings := range []models.SubmissionSettings{
+		{WithCaseSensitive: true},
+		{WithCaseSensitive: false},
+		{WithTrim: true, WithCaseSensitive: true},
+		{WithTrim: true, WithCaseSensitive: false},
+	} {
+		def, err := NewComparator(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exactSettings := settings
+		exactSettings.ComparisonMode = ComparisonExact
+		exact, err := NewComparator(exactSettings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, actual := range outputs {
+			for _, expected := range outputs {
+				legacy := legacyCompare(actual, expected, settings)
+				if got := exact.Compare(actual, expected).Equal; got != legacy {
+					t.Errorf("%+v exact: Compare(%q, %q) = %v, legacy %v", settings, actual, expected, got, legacy)
+				}
+				got := def.Compare(actual, expected).Equal
+				if legacy && !got {
+					t.Errorf("%+v default: Compare(%q, %q) rejects output accepted before", settings, actual, expected)
+				}
+				if !legacy && got && normalizeLines(actual, settings) != normalizeLines(expected, settings) {
+					t.Errorf("%+v default: Compare(%q, %q) accepts output with different content", settings, actual, expected)
+				}
+			}
+		}
+	}
+}
+
+// normalizeLines bỏ những khác biệt mà chế độ "lines" được phép bỏ qua.
+func normalizeLines(s string, settings models.SubmissionSettings) string {
+	if settings.WithTrim {
+		s = strings.TrimSpace(s)
+	}
+	if !settings.WithCaseSensitive {
+		s = strings.ToLower(s)
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
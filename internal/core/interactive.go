@@ -0,0 +1,258 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mirai3103/remote-compiler/internal/core/sandbox"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// transcriptLimit là độ dài tối đa của bản ghi tương tác trả về trong SubmissionResult.Transcript.
+const transcriptLimit = 64 * 1024
+
+// interactionResult là kết quả chạy chương trình của thí sinh cùng interactor cho một test case.
+type interactionResult struct {
+	Status     models.TestcaseStatus
+	User       *sandbox.ExecuteResult // Kết quả chạy chương trình của thí sinh (nil nếu lỗi sandbox)
+	Message    string                 // Thông báo của interactor
+	Transcript string                 // Dữ liệu trao đổi giữa hai bên, đã cắt theo transcriptLimit
+	Error      string                 // Lỗi sandbox, nếu có
+}
+
+// interact chạy interactor và chương trình của thí sinh trong hai sandbox riêng, nối stdout của bên này
+// vào stdin của bên kia qua pipe. Interactor được gọi như checker testlib: "interactor input output answer",
+// với input là input của test case, và verdict lấy từ exit code của interactor.
+func (r *Runner) interact(ctx context.Context, interactor *preparedChecker, submission models.Submission, tc models.TestCase, runReq sandbox.RunRequest) *interactionResult {
+	inputPath, outputPath, answerPath, err := interactor.writeFiles(tc, "")
+	if err != nil {
+		return &interactionResult{Status: models.InternalError, Error: fmt.Sprintf("Failed to write interactor files: %v", err)}
+	}
+
+	// Hai bên chờ nhau nên phải giữ sandbox cùng lúc: nếu mỗi bên tự lấy box từ pool, nhiều lần chạy
+	// tương tác đồng thời có thể mỗi lần chỉ giữ một box và chờ nhau mãi mãi.
+	if reserver, ok := r.sandboxExecutor.(sandbox.Reserver); ok {
+		reserved, release, err := reserver.Reserve(ctx, 2)
+		if err != nil {
+			return &interactionResult{Status: models.InternalError, Error: fmt.Sprintf("Failed to reserve sandboxes: %v", err)}
+		}
+		defer release()
+		ctx = reserved
+	}
+
+	// Hai chiều, mỗi chiều hai pipe với một goroutine ở giữa để ghi lại transcript:
+	// interactor stdout -> relay -> stdin của thí sinh, stdout của thí sinh -> relay -> interactor stdin.
+	transcript := &transcriptBuffer{limit: transcriptLimit}
+	toUser, err := newRelay(transcript, "> ")
+	if err != nil {
+		return &interactionResult{Status: models.InternalError, Error: fmt.Sprintf("Failed to create pipes: %v", err)}
+	}
+	fromUser, err := newRelay(transcript, "< ")
+	if err != nil {
+		toUser.closeAll()
+		return &interactionResult{Status: models.InternalError, Error: fmt.Sprintf("Failed to create pipes: %v", err)}
+	}
+	defer toUser.closeAll()
+	defer fromUser.closeAll()
+	var relays sync.WaitGroup
+	relays.Add(2)
+	go func() { defer relays.Done(); toUser.run() }()
+	go func() { defer relays.Done(); fromUser.run() }()
+
+	// Interactor phải sống lâu hơn chương trình của thí sinh, nên được cộng thêm time limit của submission.
+	interactorTimeLimitMs := interactor.timeLimitMs + submission.TimeLimitInMs
+	userReq := runReq
+	userReq.Input = ""
+	userReq.Stdin = toUser.destReader
+	userReq.Stdout = fromUser.srcWriter
+
+	var wg sync.WaitGroup
+	var userResult, interactorResult *sandbox.ExecuteResult
+	var userErr, interactorErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		userCtx, cancel := context.WithTimeout(ctx, time.Duration(submission.TimeLimitInMs)*time.Millisecond)
+		defer cancel()
+		userResult, userErr = r.sandboxExecutor.Execute(userCtx, userReq)
+		// Đóng bản sao của runner để interactor nhận EOF khi thí sinh kết thúc.
+		fromUser.closeSource()
+		toUser.closeDest()
+	}()
+	go func() {
+		defer wg.Done()
+		interactorCtx, cancel := context.WithTimeout(ctx, time.Duration(interactorTimeLimitMs)*time.Millisecond+compileTimeoutGrace)
+		defer cancel()
+		interactorResult, interactorErr = r.sandboxExecutor.Execute(interactorCtx, sandbox.RunRequest{
+			SubmissionID:     submission.ID,
+			TestCaseID:       tc.ID + "-interactor",
+			RunCommand:       append(append([]string{}, interactor.command...), inputPath, outputPath, answerPath),
+			WorkingDirectory: interactor.dir,
+			TimeLimitMs:      interactorTimeLimitMs,
+			MemoryLimitKb:    interactor.memoryLimitKb,
+			Stdin:            fromUser.destReader,
+			Stdout:           toUser.srcWriter,
+		})
+		// Đóng bản sao của runner để thí sinh nhận EOF khi interactor kết thúc.
+		toUser.closeSource()
+		fromUser.closeDest()
+	}()
+	wg.Wait()
+	relays.Wait()
+
+	result := &interactionResult{User: userResult, Transcript: transcript.String()}
+	switch {
+	case userErr != nil:
+		log.Printf("Sandbox execution error for TestCaseID %s, SubmissionID %s: %v", tc.ID, submission.ID, userErr)
+		result.Status = models.InternalError
+		result.Error = fmt.Sprintf("Sandbox execution failed: %v", userErr)
+		return result
+	case interactorErr != nil:
+		log.Printf("Sandbox error while running interactor for TestCaseID %s, SubmissionID %s: %v", tc.ID, submission.ID, interactorErr)
+		result.Status = models.InternalError
+		result.Error = fmt.Sprintf("Interactor execution failed: %v", interactorErr)
+		return result
+	}
+
+	verdict, message := checkerVerdict(interactorResult)
+	result.Message = message
+	switch {
+	case verdict == models.InternalError:
+		result.Status = models.InternalError // Lỗi của interactor, không phải của thí sinh
+	case userResult.Status == models.TimeLimitExceeded || userResult.Status == models.MemoryLimitExceeded:
+		// Interactor thường báo WA khi chương trình bị kill giữa chừng; TLE/MLE chính xác hơn.
+		result.Status = userResult.Status
+	case verdict != models.Success:
+		// WA/PE của interactor được ưu tiên hơn RE: thí sinh có thể chết vì SIGPIPE sau khi interactor dừng sớm.
+		result.Status = verdict
+	default:
+		result.Status = userResult.Status
+	}
+	return result
+}
+
+// relay chuyển dữ liệu từ một pipe (src) sang pipe khác (dest) và ghi lại vào transcript.
+// Tiến trình ghi nhận srcWriter, tiến trình đọc nhận destReader.
+type relay struct {
+	srcReader, srcWriter   *os.File
+	destReader, destWriter *os.File
+	transcript             *transcriptBuffer
+	prefix                 string
+
+	closeSrcOnce, closeDestOnce sync.Once
+}
+
+func newRelay(transcript *transcriptBuffer, prefix string) (*relay, error) {
+	srcReader, srcWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	destReader, destWriter, err := os.Pipe()
+	if err != nil {
+		srcReader.Close()
+		srcWriter.Close()
+		return nil, err
+	}
+	return &relay{
+		srcReader:  srcReader,
+		srcWriter:  srcWriter,
+		destReader: destReader,
+		destWriter: destWriter,
+		transcript: transcript,
+		prefix:     prefix,
+	}, nil
+}
+
+// run copy từ src sang dest cho tới khi mọi đầu ghi của src đã đóng. Nếu bên đọc đã kết thúc,
+// dữ liệu còn lại vẫn được đọc hết để bên ghi không bị treo vì pipe đầy.
+func (rl *relay) run() {
+	buf := make([]byte, 32*1024)
+	destOpen := true
+	for {
+		n, err := rl.srcReader.Read(buf)
+		if n > 0 {
+			rl.transcript.Write(rl.prefix, buf[:n])
+			if destOpen {
+				if _, werr := rl.destWriter.Write(buf[:n]); werr != nil {
+					destOpen = false
+				}
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	rl.destWriter.Close() // Bên đọc nhận EOF
+	rl.srcReader.Close()
+}
+
+// closeSource đóng đầu ghi của src mà runner đang giữ, sau khi tiến trình ghi đã kết thúc.
+func (rl *relay) closeSource() {
+	rl.closeSrcOnce.Do(func() { rl.srcWriter.Close() })
+}
+
+// closeDest đóng đầu đọc của dest mà runner đang giữ, sau khi tiến trình đọc đã kết thúc.
+func (rl *relay) closeDest() {
+	rl.closeDestOnce.Do(func() { rl.destReader.Close() })
+}
+
+// closeAll đóng mọi file descriptor còn mở (dùng khi thoát sớm hoặc sau khi đã xong).
+func (rl *relay) closeAll() {
+	rl.closeSource()
+	rl.closeDest()
+	rl.srcReader.Close()
+	rl.destWriter.Close()
+}
+
+// transcriptBuffer ghi lại dữ liệu hai chiều, mỗi dòng có tiền tố cho biết chiều ("> " từ interactor
+// tới thí sinh, "< " từ thí sinh tới interactor), và cắt khi vượt quá limit.
+type transcriptBuffer struct {
+	mu         sync.Mutex
+	b          strings.Builder
+	limit      int
+	lastPrefix string
+	atLineHead bool
+	truncated  bool
+}
+
+func (t *transcriptBuffer) Write(prefix string, data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.truncated {
+		return
+	}
+	if prefix != t.lastPrefix && t.b.Len() > 0 && !t.atLineHead {
+		t.b.WriteByte('\n') // Đổi chiều giữa dòng
+		t.atLineHead = true
+	}
+	if t.b.Len() == 0 {
+		t.atLineHead = true
+	}
+	t.lastPrefix = prefix
+	for _, c := range data {
+		if t.b.Len() >= t.limit {
+			t.b.WriteString("\n... (transcript truncated)")
+			t.truncated = true
+			return
+		}
+		if t.atLineHead {
+			t.b.WriteString(prefix)
+			t.atLineHead = false
+		}
+		t.b.WriteByte(c)
+		if c == '\n' {
+			t.atLineHead = true
+		}
+	}
+}
+
+func (t *transcriptBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.b.String()
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"log"
+
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// ProcessSubmissionWith chấm submission giống ProcessSubmission nhưng gửi kết quả cho reporter thay vì
+// publish lên NATS. Submission bị từ chối không được chuyển vào dead-letter vì người gửi nhận lý do
+// qua reporter.
+func (r *Runner) ProcessSubmissionWith(ctx context.Context, submission models.Submission, reporter Reporter) {
+	if err := r.process(ctx, submission, reporter); err != nil {
+		log.Printf("SubmissionID %s was rejected: %v", submission.ID, err)
+	}
+}
+
+// RunSubmission chấm submission và trả về mọi kết quả thay vì publish chúng, dùng cho request-reply
+// (nút "Run code"). Chỉ sự kiện tiến độ vẫn được publish lên NATS.
+func (r *Runner) RunSubmission(ctx context.Context, submission models.Submission) models.RunSubmissionResponse {
+	collector := &resultCollector{progress: r.natsPublisher}
+	r.ProcessSubmissionWith(ctx, submission, collector)
+	collector.response.SubmissionID = submission.ID
+	if collector.response.Results == nil {
+		collector.response.Results = []models.SubmissionResult{}
+	}
+	return collector.response
+}
+
+// resultCollector là Reporter gom kết quả của một submission vào RunSubmissionResponse.
+// resultTracker gọi nó tuần tự nên không cần khóa.
+type resultCollector struct {
+	response models.RunSubmissionResponse
+	progress Reporter // Nhận sự kiện tiến độ
+}
+
+func (c *resultCollector) PublishSubmissionResult(result models.SubmissionResult) error {
+	c.response.Results = append(c.response.Results, result)
+	return nil
+}
+
+func (c *resultCollector) PublishSubmissionScore(score models.SubmissionScore) error {
+	c.response.Score = &score
+	return nil
+}
+
+func (c *resultCollector) PublishSubmissionSummary(summary models.SubmissionSummary) error {
+	c.response.Summary = summary
+	return nil
+}
+
+func (c *resultCollector) PublishSubmissionProgress(progress models.SubmissionProgress) {
+	c.progress.PublishSubmissionProgress(progress)
+}
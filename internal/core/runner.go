@@ -2,52 +2,176 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
-	// Để lấy thông tin ngôn ngữ từ languages.json
 	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/core/cache"
 	"github.com/Mirai3103/remote-compiler/internal/core/sandbox" // Interface Executor và các struct RunRequest, ExecuteResult
+	"github.com/Mirai3103/remote-compiler/internal/language"     // Registry ngôn ngữ (config "languages")
 	"github.com/Mirai3103/remote-compiler/internal/models"
 	"github.com/Mirai3103/remote-compiler/internal/nats" // NATS Publisher
 )
 
+// Giới hạn cho bước biên dịch (chạy bên trong sandbox).
+const (
+	defaultCompileTimeout          = 30 * time.Second // Dùng khi runner.compilationTimeoutSec không được đặt
+	compileTimeoutGrace            = 5 * time.Second  // Thời gian để sandbox tự báo compile timeout trước khi context bị hủy
+	defaultCompileMemoryLimitKb    = 1024 * 1024      // 1 GB, dùng khi runner.compilationMemoryLimitKb không được đặt
+	defaultCompileFileSizeLimitKb  = 256 * 1024       // 256 MB
+	defaultCompileMaxProcesses     = 256
+	defaultCompileOutputLimitBytes = 64 * 1024
+)
+
+// Các giá trị của settings.stopOnFirstFailure.
+const (
+	StopNever   = "never" // Chạy mọi test case (mặc định)
+	StopOnAny   = "any"   // Dừng sau test case sai đầu tiên, bất kể lý do
+	StopOnFatal = "fatal" // Chỉ dừng sau TLE, MLE hoặc RE; WA vẫn chạy tiếp
+)
+
+// shouldStop cho biết có dừng chạy các test case còn lại sau một test case có status này hay không.
+func shouldStop(policy string, status models.TestcaseStatus) bool {
+	switch policy {
+	case StopOnAny:
+		return status != models.Success && status != models.Skipped
+	case StopOnFatal:
+		return status == models.TimeLimitExceeded || status == models.MemoryLimitExceeded || status == models.RuntimeError
+	default:
+		return false
+	}
+}
+
+// ErrCancelled là cause (context.Cause) của context submission khi submission bị hủy qua subject
+// submission.cancel, để phân biệt với việc hết thời gian xử lý.
+var ErrCancelled = errors.New("submission cancelled")
+
+// cancelReason trả về lý do hủy nếu ctx đã bị hủy bằng ErrCancelled.
+func cancelReason(ctx context.Context) (string, bool) {
+	if ctx.Err() == nil {
+		return "", false
+	}
+	if cause := context.Cause(ctx); errors.Is(cause, ErrCancelled) {
+		return cause.Error(), true
+	}
+	return "", false
+}
+
 // Runner orchestrates the code compilation (if needed) and execution for a submission.
 type Runner struct {
 	sandboxExecutor sandbox.Executor // Một instance của sandbox executor (ví dụ: FirejailExecutor)
 	natsPublisher   *nats.Publisher  // Để publish kết quả từng test case
 	runnerConfig    *config.RunnerConfig
+	compileCache    *cache.CompileCache // nil nếu runner.compileCache.enabled = false
+	languages       *language.Registry  // Ngôn ngữ được hỗ trợ; lệnh biên dịch/chạy chỉ lấy từ đây
 }
 
 // NewRunner creates a new Runner instance.
-func NewRunner(executor sandbox.Executor, publisher *nats.Publisher, runnerConfig *config.RunnerConfig) *Runner {
-	return &Runner{
+func NewRunner(executor sandbox.Executor, publisher *nats.Publisher, runnerConfig *config.RunnerConfig, languages *language.Registry) *Runner {
+	r := &Runner{
 		sandboxExecutor: executor,
 		natsPublisher:   publisher,
 		runnerConfig:    runnerConfig,
+		languages:       languages,
+	}
+	if unknown := unknownCompileLimits(runnerConfig.CompileLimits, languages.IDs()); len(unknown) > 0 {
+		// Không phải lỗi, nhưng thường là lỗi gõ language ID: giới hạn đó sẽ không bao giờ được dùng.
+		log.Printf("Warning: runner.compileLimits has entries for unregistered languages %v (registered: %v); they are ignored",
+			unknown, languages.IDs())
+	}
+	if cc := runnerConfig.CompileCache; cc.Enabled {
+		compileCache, err := cache.New(cc.Dir, int64(cc.MaxSizeMb)*1024*1024)
+		if err != nil {
+			// Không có cache thì vẫn chấm được, chỉ chậm hơn.
+			log.Printf("Compile cache disabled: %v", err)
+		} else {
+			r.compileCache = compileCache
+		}
 	}
+	return r
 }
 
 // ProcessSubmission là hàm chính xử lý toàn bộ submission.
 // Nó được gọi bởi worker.JobHandler.
 func (r *Runner) ProcessSubmission(ctx context.Context, submission models.Submission) {
-	log.Printf("Processing SubmissionID: %s, Language: %s", submission.ID, submission.Language.RunCommand) // Giả sử client gửi LanguageID
+	if err := r.process(ctx, submission, r.natsPublisher); err != nil {
+		r.publishDeadLetter(submission, err)
+	}
+}
 
-	// 1. Lấy cấu hình chi tiết cho ngôn ngữ từ `languages.json`
-	langDetails := submission.Language
+// process chấm submission và gửi mọi kết quả qua reporter. Lỗi trả về là lý do submission bị từ chối
+// (Rejected); các lỗi khác đã được báo qua reporter.
+func (r *Runner) process(ctx context.Context, submission models.Submission, reporter Reporter) error {
+	log.Printf("Processing SubmissionID: %s, Language: %s", submission.ID, submission.LanguageKey())
+	// Mọi kết quả đều đi qua tracker; message tổng kết được publish một lần khi hàm kết thúc.
+	tracker := newResultTracker(submission, reporter)
+	defer tracker.finish()
+	if reason, ok := cancelReason(ctx); ok { // Bị hủy khi còn đang chờ slot
+		log.Printf("SubmissionID %s was cancelled before it started: %s", submission.ID, reason)
+		r.publishCancelled(tracker, submission, submission.TestCases, "", reason)
+		return nil
+	}
+
+	// 1. Lấy cấu hình chi tiết cho ngôn ngữ từ registry của runner.
+	// Không bao giờ dùng lệnh do client gửi: bất kỳ ai publish được lên NATS đều có thể gửi lệnh tùy ý.
+	// ID cũng do client gửi và là tên thư mục tạm, nên được kiểm tra trước khi đụng tới filesystem.
+	err := models.ValidateSubmissionID(submission.ID)
+	var langDetails language.Language
+	if err == nil {
+		langDetails, err = r.languages.Resolve(submission.LanguageKey(), submission.CompileFlags)
+	}
+	var comparator Comparator // Chọn theo submission.Settings; mode không hợp lệ cũng bị từ chối
+	if err == nil {
+		comparator, err = NewComparator(submission.Settings)
+	}
+	var checker *checkerSpec // Khác nil nếu submission dùng special judge thay cho comparator
+	if err == nil {
+		checker, err = r.resolveChecker(submission.Checker)
+	}
+	var interactor *checkerSpec // Khác nil nếu là bài tương tác
+	if err == nil {
+		if submission.Interactor != nil && submission.Checker != nil {
+			err = fmt.Errorf("checker and interactor cannot be used together")
+		} else if interactor, err = r.resolveChecker(submission.Interactor); err != nil {
+			err = fmt.Errorf("interactor: %w", err)
+		}
+	}
+	var board *scoreboard // Khác nil nếu submission có subtask
+	if err == nil {
+		board, err = newScoreboard(submission)
+	}
+	if err == nil {
+		switch submission.Settings.StopOnFirstFailure {
+		case "", StopNever, StopOnAny, StopOnFatal:
+		default:
+			err = fmt.Errorf("unknown stopOnFirstFailure policy %q", submission.Settings.StopOnFirstFailure)
+		}
+	}
+	if err != nil {
+		log.Printf("Rejecting SubmissionID %s: %v", submission.ID, err)
+		r.publishOverallError(tracker, submission, models.Rejected, err.Error())
+		return err
+	}
+	// Điểm được publish khi submission kết thúc, kể cả khi biên dịch lỗi.
+	tracker.board = board
 
 	// 2. Tạo thư mục tạm duy nhất cho submission này
-	var tempDir string = r.runnerConfig.SandboxBaseDir + "/" + submission.ID
-	err := os.MkdirAll(tempDir, 0755)
+	// Đường dẫn tuyệt đối vì thư mục này được mount vào sandbox.
+	tempDir, err := filepath.Abs(filepath.Join(r.runnerConfig.SandboxBaseDir, submission.ID))
+	if err == nil {
+		err = os.MkdirAll(tempDir, 0755)
+	}
 	if err != nil {
 		log.Printf("Error creating temp directory for SubmissionID %s: %v", submission.ID, err)
-		r.publishOverallError(submission.ID, models.InternalError, "Failed to create temp environment.")
-		return
+		r.publishOverallError(tracker, submission, models.InternalError, "Failed to create temp environment.")
+		return nil
 	}
 	defer func() {
 		if err := os.RemoveAll(tempDir); err != nil {
@@ -62,76 +186,145 @@ func (r *Runner) ProcessSubmission(ctx context.Context, submission models.Submis
 	sourceFilePath := filepath.Join(tempDir, langDetails.SourceFile)
 	if err := os.WriteFile(sourceFilePath, []byte(submission.Code), 0644); err != nil {
 		log.Printf("Error writing source code for SubmissionID %s: %v", submission.ID, err)
-		r.publishOverallError(submission.ID, models.InternalError, "Failed to write source code.")
-		return
+		r.publishOverallError(tracker, submission, models.InternalError, "Failed to write source code.")
+		return nil
 	}
 	log.Printf("Source code written to: %s", sourceFilePath)
 
 	// 4. Bước Biên Dịch (nếu ngôn ngữ yêu cầu)
-	executablePath := sourceFilePath      // Mặc định cho ngôn ngữ thông dịch
-	if langDetails.CompileCommand != "" { // nếu có compile command
-		compileCmdTemplate := strings.Fields(langDetails.CompileCommand) // ["go", "build", "-o", "{output_file}", "{source_file}"]
-
-		outputFileName := langDetails.BinaryFile // Ví dụ: "a.out" hoặc "main"
-		if outputFileName == "" {
-			outputFileName = "executable" // Tên mặc định nếu không có trong config
-		}
+	templateVars := language.Vars{
+		SourceFile:    sourceFilePath,
+		TempDir:       tempDir,
+		MemoryLimitKb: submission.MemoryLimitInKb,
+		TimeLimitMs:   submission.TimeLimitInMs,
+	}
+	compileCacheStatus := "" // models.CompileCacheHit / CompileCacheMiss khi compile cache được bật
+	if langDetails.IsCompiled() {
 		//todo: check file extension
-		compiledExecutablePath := filepath.Join(tempDir, outputFileName)
+		compiledExecutablePath := filepath.Join(tempDir, langDetails.BinaryFile) // Ví dụ: "a.out" hoặc "main"
+		templateVars.OutputFile = compiledExecutablePath
 
-		actualCompileCmd := make([]string, len(compileCmdTemplate))
-		for i, part := range compileCmdTemplate {
-			part = strings.ReplaceAll(part, "{source_file}", sourceFilePath)
-			part = strings.ReplaceAll(part, "{output_file}", compiledExecutablePath)
-			// Bạn có thể thêm các placeholder khác như {temp_dir}
-			part = strings.ReplaceAll(part, "{temp_dir}", tempDir)
-			actualCompileCmd[i] = part
+		tracker.progress(models.SubmissionProgress{Stage: models.StageCompiling})
+		compileResult, cacheStatus, compileErr := r.compileCached(ctx, submission.ID, langDetails, submission.Code, templateVars)
+		compileCacheStatus = cacheStatus
+		tracker.summary.CompileCache = cacheStatus
+		if reason, ok := cancelReason(ctx); ok { // Tiến trình biên dịch đã bị kill
+			log.Printf("SubmissionID %s was cancelled during compilation: %s", submission.ID, reason)
+			r.publishCancelled(tracker, submission, submission.TestCases, compileCacheStatus, reason)
+			return nil
+		}
+		if compileErr != nil { // Lỗi của chính sandbox, không phải lỗi biên dịch của user
+			log.Printf("Sandbox compile error for SubmissionID %s: %v", submission.ID, compileErr)
+			tracker.fail(models.InternalError, fmt.Sprintf("Sandbox compilation failed: %v", compileErr))
+			for _, tc := range submission.TestCases {
+				tracker.publish(models.SubmissionResult{
+					SubmissionID: submission.ID,
+					TestCaseID:   tc.ID,
+					Status:       models.InternalError,
+					Error:        fmt.Sprintf("Sandbox compilation failed: %v", compileErr),
+					CompileCache: compileCacheStatus,
+				})
+			}
+			return nil
 		}
 
-		log.Printf("Compiling SubmissionID %s with command: %v", submission.ID, actualCompileCmd)
-
-		// Set timeout cho quá trình biên dịch (ví dụ: 30 giây)
-		compileCtx, compileCancel := context.WithTimeout(ctx, 30*time.Second)
-		defer compileCancel()
-
-		cmd := exec.CommandContext(compileCtx, actualCompileCmd[0], actualCompileCmd[1:]...)
-		cmd.Dir = tempDir                                 // Chạy lệnh biên dịch từ thư mục tạm
-		compileOutput, compileErr := cmd.CombinedOutput() // Lấy cả stdout và stderr của trình biên dịch
-
-		if compileErr != nil {
-			log.Printf("Compilation failed for SubmissionID %s: %v. Output: %s", submission.ID, compileErr, string(compileOutput))
-			// Gửi kết quả Compile Error cho tất cả test cases hoặc một kết quả tổng
+		tracker.summary.CompileLog = compileResult.Output // Warning của trình biên dịch, kể cả khi biên dịch thành công
+		if compileResult.Status != models.Success {
+			log.Printf("Compilation failed for SubmissionID %s: %s. Output: %s", submission.ID, compileResult.Status, compileResult.Output)
+			tracker.fail(compileResult.Status, "")
+			// Gửi kết quả Compile Error (hoặc compile TLE/MLE) cho tất cả test cases
 			for _, tc := range submission.TestCases {
 				result := models.SubmissionResult{
+					SubmissionID:   submission.ID,
+					TestCaseID:     tc.ID,
+					Status:         compileResult.Status,
+					TimeUsedInMs:   compileResult.TimeUsedMs,
+					MemoryUsedInKb: compileResult.MemoryUsedKb,
+					Error:          compileResult.Output, // Gửi output lỗi biên dịch
+					CompileCache:   compileCacheStatus,
+				}
+				tracker.publish(result)
+			}
+			return nil // Dừng xử lý nếu biên dịch lỗi
+		}
+		log.Printf("Compilation successful for SubmissionID %s. Executable at: %s", submission.ID, compiledExecutablePath)
+		tracker.progress(models.SubmissionProgress{Stage: models.StageCompiled})
+	}
+
+	// Chuẩn bị checker (nếu có) một lần cho cả submission, trong thư mục riêng cạnh thư mục của user code.
+	// Interactor dùng chung cách chuẩn bị với checker.
+	var preparedChecker *preparedChecker
+	checkerPattern := ".checker-*"
+	if interactor != nil {
+		checker, checkerPattern = interactor, ".interactor-*"
+	}
+	if checker != nil {
+		var checkerDir string
+		checkerDir, err = newCheckerDir(tempDir, checkerPattern)
+		if err == nil {
+			defer func() {
+				if err := os.RemoveAll(checkerDir); err != nil {
+					log.Printf("Error removing checker directory %s: %v", checkerDir, err)
+				}
+			}()
+			preparedChecker, err = r.prepareChecker(ctx, submission.ID, checker, checkerDir)
+		}
+		if reason, ok := cancelReason(ctx); ok {
+			log.Printf("SubmissionID %s was cancelled while preparing the checker: %s", submission.ID, reason)
+			r.publishCancelled(tracker, submission, submission.TestCases, compileCacheStatus, reason)
+			return nil
+		}
+		if err != nil {
+			log.Printf("Failed to prepare checker for SubmissionID %s: %v", submission.ID, err)
+			tracker.fail(models.InternalError, err.Error())
+			for _, tc := range submission.TestCases {
+				tracker.publish(models.SubmissionResult{
 					SubmissionID: submission.ID,
 					TestCaseID:   tc.ID,
-					Status:       models.CompileError,
-					Error:        string(compileOutput), // Gửi output lỗi biên dịch
-				}
-				r.natsPublisher.PublishSubmissionResult(result)
+					Status:       models.InternalError,
+					Error:        err.Error(),
+					CompileCache: compileCacheStatus,
+				})
 			}
-			return // Dừng xử lý nếu biên dịch lỗi
+			return nil
 		}
-		executablePath = compiledExecutablePath // Cập nhật đường dẫn file thực thi
-		log.Printf("Compilation successful for SubmissionID %s. Executable at: %s", submission.ID, executablePath)
 	}
 
 	// 5. Chuẩn bị Lệnh Chạy cho Sandbox
-	// langDetails.RunCmd là template từ languages.json, ví dụ: "./{executable}" hoặc "python3 {source_file}"
-	runCmdTemplate := strings.Fields(langDetails.RunCommand)
-	actualRunCmd := make([]string, len(runCmdTemplate))
-	for i, part := range runCmdTemplate {
-		part = strings.ReplaceAll(part, "{executable}", executablePath)  // Nếu đã biên dịch
-		part = strings.ReplaceAll(part, "{source_file}", sourceFilePath) // Nếu là script
-		// Bạn có thể thêm các placeholder khác như {temp_dir}
-		part = strings.ReplaceAll(part, "{temp_dir}", tempDir)
-		actualRunCmd[i] = part
-	}
+	// langDetails.RunCommand là template từ registry ngôn ngữ, ví dụ: "{executable}" hoặc "python3 {source_file}"
+	actualRunCmd := langDetails.RunArgs(templateVars)
 	log.Printf("Prepared run command for SubmissionID %s: %v", submission.ID, actualRunCmd)
 
 	// 6. Chạy từng Test Case
-	for _, tc := range submission.TestCases {
+	stoppedAt := "" // TestCaseID làm submission dừng sớm theo settings.stopOnFirstFailure
+	for i, tc := range submission.TestCases {
+		if reason, ok := cancelReason(ctx); ok {
+			log.Printf("SubmissionID %s was cancelled before TestCaseID %s: %s", submission.ID, tc.ID, reason)
+			r.publishCancelled(tracker, submission, submission.TestCases[i:], compileCacheStatus, reason)
+			break
+		}
+		if stoppedAt != "" || (board != nil && board.shouldSkip(tc.ID)) {
+			reason := "subtask already failed"
+			if stoppedAt != "" {
+				reason = fmt.Sprintf("stopped after test case %s failed", stoppedAt)
+			}
+			log.Printf("Skipping TestCaseID %s for SubmissionID %s: %s", tc.ID, submission.ID, reason)
+			tracker.publish(models.SubmissionResult{
+				SubmissionID: submission.ID,
+				TestCaseID:   tc.ID,
+				Status:       models.Skipped,
+				Error:        "Skipped: " + reason,
+				CompileCache: compileCacheStatus,
+			})
+			continue
+		}
 		log.Printf("Running TestCaseID: %s for SubmissionID: %s", tc.ID, submission.ID)
+		tracker.progress(models.SubmissionProgress{
+			Stage:         models.StageRunning,
+			Status:        models.Running,
+			TestCaseID:    tc.ID,
+			TestCaseIndex: i + 1,
+		})
 
 		// Tạo context với timeout cho test case này
 		runCtx, runCancel := context.WithTimeout(ctx, time.Duration(submission.TimeLimitInMs)*time.Millisecond)
@@ -147,15 +340,24 @@ func (r *Runner) ProcessSubmission(ctx context.Context, submission models.Submis
 			MemoryLimitKb:    submission.MemoryLimitInKb,
 		}
 
-		// Gọi Executor để chạy code trong sandbox
-		execResult, err := r.sandboxExecutor.Execute(runCtx, sandboxReq)
-
 		finalStatus := models.TestcaseStatus("")
-		var output, execErrorMsg string
+		var output, execErrorMsg, checkerMessage, transcript string
 		timeUsed := 0
 		memoryUsed := 0
 
-		if err != nil { // Lỗi từ chính sandbox executor (không phải lỗi của code user)
+		if interactor != nil {
+			// Bài tương tác: không có output để so sánh, verdict lấy từ interactor.
+			interaction := r.interact(ctx, preparedChecker, submission, tc, sandboxReq)
+			finalStatus = interaction.Status
+			execErrorMsg = interaction.Error
+			checkerMessage = interaction.Message
+			transcript = interaction.Transcript
+			if interaction.User != nil {
+				execErrorMsg = joinNonEmpty(execErrorMsg, interaction.User.Stderr)
+				timeUsed = interaction.User.TimeUsedMs
+				memoryUsed = interaction.User.MemoryUsedKb
+			}
+		} else if execResult, err := r.sandboxExecutor.Execute(runCtx, sandboxReq); err != nil { // Lỗi từ chính sandbox executor (không phải lỗi của code user)
 			log.Printf("Sandbox execution error for TestCaseID %s, SubmissionID %s: %v", tc.ID, submission.ID, err)
 			finalStatus = models.InternalError
 			execErrorMsg = fmt.Sprintf("Sandbox execution failed: %v", err)
@@ -168,16 +370,25 @@ func (r *Runner) ProcessSubmission(ctx context.Context, submission models.Submis
 
 			// Nếu sandbox chạy thành công (code người dùng có thể vẫn lỗi runtime, TLE, MLE)
 			// và status trả về là Success (nghĩa là code chạy xong trong giới hạn)
-			// thì mới cần so sánh output.
-			if finalStatus == models.Success {
-				if r.compareOutput(output, tc.ExpectOutput, submission.Settings) {
-					finalStatus = models.Success
-				} else {
+			// thì mới cần so sánh output (bằng checker nếu submission có, nếu không thì bằng comparator).
+			if finalStatus == models.Success && preparedChecker != nil {
+				finalStatus, checkerMessage = r.check(ctx, preparedChecker, submission.ID, tc, output)
+			} else if finalStatus == models.Success {
+				compared := comparator.Compare(output, tc.ExpectOutput)
+				if !compared.Equal {
 					finalStatus = models.WrongAnswer
+					// Chỗ khác nhau đầu tiên được báo trong Error, trước stderr của user code.
+					execErrorMsg = joinNonEmpty(compared.Message, execErrorMsg)
 				}
 			}
 		}
 
+		// Bị hủy trong lúc chạy: tiến trình đã bị kill nên status của sandbox (TLE, lỗi...) không có nghĩa.
+		if reason, ok := cancelReason(ctx); ok {
+			tracker.cancel(reason)
+			finalStatus, output, execErrorMsg, checkerMessage = models.Cancelled, "", reason, ""
+		}
+
 		// Chuẩn bị và gửi kết quả của test case này
 		result := models.SubmissionResult{
 			SubmissionID:   submission.ID,
@@ -187,54 +398,249 @@ func (r *Runner) ProcessSubmission(ctx context.Context, submission models.Submis
 			MemoryUsedInKb: memoryUsed,
 			Output:         output,       // stdout của user code
 			Error:          execErrorMsg, // stderr của user code hoặc lỗi sandbox
+			CompileCache:   compileCacheStatus,
+			CheckerMessage: checkerMessage,
+			Transcript:     transcript,
 		}
-		r.natsPublisher.PublishSubmissionResult(result)
+		tracker.publish(result)
 		log.Printf("Result for TestCaseID %s, SubmissionID %s: Status=%s, Time=%dms, Mem=%dkB",
 			tc.ID, submission.ID, result.Status, result.TimeUsedInMs, result.MemoryUsedInKb)
 
-		// (Tùy chọn) Nếu gặp lỗi nghiêm trọng (không phải WA) thì có thể dừng chạy các test case còn lại
-		if finalStatus != models.Success && finalStatus != models.WrongAnswer {
+		// Dừng sớm theo settings.stopOnFirstFailure; các test case còn lại vẫn được báo là Skipped
+		// để consumer không phải chờ kết quả không bao giờ tới.
+		if shouldStop(submission.Settings.StopOnFirstFailure, finalStatus) {
 			log.Printf("Stopping further test cases for SubmissionID %s due to status: %s on TestCaseID: %s",
 				submission.ID, finalStatus, tc.ID)
-			// break // Bỏ comment nếu muốn dừng sớm
+			stoppedAt = tc.ID
 		}
 	}
 
 	log.Printf("Finished processing SubmissionID: %s", submission.ID)
+	return nil
+}
+
+// compileExecutables trả về file thực thi (trên host) của từng bước biên dịch, tìm giống như sandbox tìm,
+// để compile cache nhận biết mọi công cụ trong toolchain chứ không chỉ bước đầu tiên.
+// File nằm trong thư mục của submission (ví dụ ./gradlew) là một phần của source nên được bỏ qua;
+// lệnh không tìm được vẫn được tính theo tên.
+func (r *Runner) compileExecutables(compileSteps [][]string, tempDir string) []string {
+	var executables []string
+	seen := map[string]bool{}
+	for _, step := range compileSteps {
+		path, err := r.sandboxExecutor.ResolveExecutable(step[0])
+		if err != nil {
+			path = step[0]
+		} else if !filepath.IsAbs(path) || strings.HasPrefix(path, tempDir+string(filepath.Separator)) {
+			continue // Đường dẫn tương đối được tính theo thư mục của submission
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		executables = append(executables, path)
+	}
+	return executables
 }
 
-// compareOutput so sánh output thực tế với output mong đợi
-func (r *Runner) compareOutput(actual, expected string, settings models.SubmissionSettings) bool {
-	if settings.WithTrim {
-		actual = strings.TrimSpace(actual)
-		expected = strings.TrimSpace(expected)
+// compileCached biên dịch code theo lang trong vars.TempDir, dùng compile cache nếu được bật.
+// Trả về thêm trạng thái cache (models.CompileCacheHit / CompileCacheMiss, rỗng nếu cache bị tắt).
+// Khi cache hit, binary được copy vào vars.OutputFile và CompileResult có Status = Success.
+func (r *Runner) compileCached(ctx context.Context, submissionID string, lang language.Language, code string, vars language.Vars) (*sandbox.CompileResult, string, error) {
+	compileSteps := lang.CompileSteps(vars) // [["go", "build", "-o", "/tmp/.../main", "/tmp/.../main.go"]]
+
+	// Tra compile cache trước: rejudge hoặc nộp lại cùng source code thì không cần biên dịch lại.
+	// Key dùng template của lệnh biên dịch, vì lệnh thực tế chứa đường dẫn riêng của submission.
+	cacheKey, cacheStatus := "", ""
+	if r.compileCache != nil {
+		compileCommand := lang.CompileCommand + " " + strings.Join(lang.Flags, " ")
+		toolchain := cache.ToolchainVersion(r.compileExecutables(compileSteps, vars.TempDir)...)
+		cacheKey = cache.Key(lang.ID, compileCommand, toolchain, code)
+		hit, err := r.compileCache.Get(cacheKey, vars.OutputFile)
+		if err != nil {
+			log.Printf("Compile cache lookup failed for SubmissionID %s: %v", submissionID, err)
+		}
+		if hit {
+			cacheStatus = models.CompileCacheHit
+		} else {
+			cacheStatus = models.CompileCacheMiss
+		}
+		log.Printf("Compile cache %s for SubmissionID %s (key %s)", cacheStatus, submissionID, cacheKey)
+		if hit {
+			return &sandbox.CompileResult{Status: models.Success}, cacheStatus, nil
+		}
+	}
+
+	log.Printf("Compiling SubmissionID %s with command: %v", submissionID, compileSteps)
+	compileResult, err := r.compileSource(ctx, submissionID, lang.ID, compileSteps, vars.TempDir)
+	if err != nil || compileResult.Status != models.Success {
+		return compileResult, cacheStatus, err
 	}
-	if !settings.WithCaseSensitive {
-		actual = strings.ToLower(actual)
-		expected = strings.ToLower(expected)
+
+	// Chỉ cache khi output là một file (ví dụ: Java sinh nhiều file .class thì bỏ qua).
+	if cacheKey != "" {
+		if info, err := os.Stat(vars.OutputFile); err == nil && info.Mode().IsRegular() {
+			if err := r.compileCache.Put(cacheKey, vars.OutputFile); err != nil {
+				log.Printf("Failed to store compiled binary of SubmissionID %s in compile cache: %v", submissionID, err)
+			}
+		}
 	}
-	// TODO: Xử lý `settings.WithWhitespace` (ví dụ: chuẩn hóa nhiều khoảng trắng thành 1, bỏ qua dòng trống cuối...)
-	// Hiện tại, so sánh chính xác sau khi trim và xử lý case.
-	return actual == expected
+	return compileResult, cacheStatus, nil
+}
+
+// compileSource chạy các bước biên dịch lần lượt bên trong sandbox với giới hạn biên dịch của ngôn ngữ,
+// dừng ở bước đầu tiên thất bại. Giới hạn thời gian áp dụng cho tổng các bước.
+// Lỗi trả về là lỗi của sandbox; lỗi biên dịch của user nằm trong CompileResult.Status.
+func (r *Runner) compileSource(ctx context.Context, submissionID, languageID string, steps [][]string, tempDir string) (*sandbox.CompileResult, error) {
+	// Biên dịch bên trong sandbox với giới hạn riêng. Context chỉ là chốt chặn cuối,
+	// sandbox tự phát hiện compile timeout / MLE theo TimeLimitMs và MemoryLimitKb.
+	compileTimeout, compileMemoryLimitKb := r.compileLimits(languageID)
+	compileCtx, compileCancel := context.WithTimeout(ctx, compileTimeout+compileTimeoutGrace)
+	defer compileCancel()
+
+	total := &sandbox.CompileResult{Status: models.Success}
+	var output strings.Builder
+	for _, step := range steps {
+		remaining := compileTimeout - time.Duration(total.TimeUsedMs)*time.Millisecond
+		if remaining <= 0 {
+			total.Status = models.CompileTimeout
+			break
+		}
+		stepResult, err := r.sandboxExecutor.Compile(compileCtx, sandbox.CompileRequest{
+			SubmissionID:     submissionID,
+			CompileCommand:   step,
+			WorkingDirectory: tempDir,
+			TimeLimitMs:      int(remaining.Milliseconds()),
+			MemoryLimitKb:    compileMemoryLimitKb,
+			FileSizeLimitKb:  defaultCompileFileSizeLimitKb,
+			MaxProcesses:     defaultCompileMaxProcesses,
+			MaxOutputBytes:   defaultCompileOutputLimitBytes,
+			Env:              []string{"HOME=" + tempDir}, // cache của go/cargo... nằm trong thư mục tạm
+		})
+		if err != nil {
+			if errors.Is(compileCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+				// Sandbox không kịp tự báo timeout, context của bước biên dịch đã hết hạn.
+				log.Printf("Compile context deadline exceeded for SubmissionID %s: %v", submissionID, err)
+				return &sandbox.CompileResult{
+					Status:     models.CompileTimeout,
+					Output:     fmt.Sprintf("Compilation exceeded the time limit of %s.", compileTimeout),
+					TimeUsedMs: int(compileTimeout.Milliseconds()),
+				}, nil
+			}
+			return nil, err
+		}
+		output.WriteString(stepResult.Output)
+		total.TimeUsedMs += stepResult.TimeUsedMs
+		total.MemoryUsedKb = max(total.MemoryUsedKb, stepResult.MemoryUsedKb)
+		total.ExitCode = stepResult.ExitCode
+		if stepResult.Status != models.Success {
+			total.Status = stepResult.Status
+			break
+		}
+	}
+	total.Output = output.String()
+	if total.Status == models.CompileTimeout && total.Output == "" {
+		total.Output = fmt.Sprintf("Compilation exceeded the time limit of %s.", compileTimeout)
+	}
+	return total, nil
+}
+
+// unknownCompileLimits trả về các key của runner.compileLimits (đã sắp xếp) không khớp language ID nào.
+// Viper chuyển key của map về chữ thường, nên so sánh với ID đã chuyển về chữ thường như compileLimits().
+func unknownCompileLimits(limits map[string]config.CompileLimitConfig, languageIDs []string) []string {
+	known := make(map[string]bool, len(languageIDs))
+	for _, id := range languageIDs {
+		known[strings.ToLower(id)] = true
+	}
+	var unknown []string
+	for key := range limits {
+		if !known[strings.ToLower(key)] {
+			unknown = append(unknown, key)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// compileLimits trả về thời gian và bộ nhớ tối đa cho bước biên dịch của một ngôn ngữ:
+// giá trị trong runner.compileLimits[languageID] nếu có, nếu không thì giá trị chung.
+func (r *Runner) compileLimits(languageID string) (time.Duration, int) {
+	timeout := time.Duration(r.runnerConfig.CompilationTimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = defaultCompileTimeout
+	}
+	memoryLimitKb := r.runnerConfig.CompilationMemoryLimitKb
+	if memoryLimitKb <= 0 {
+		memoryLimitKb = defaultCompileMemoryLimitKb
+	}
+	if override, ok := r.runnerConfig.CompileLimits[strings.ToLower(languageID)]; ok {
+		if override.TimeoutSec > 0 {
+			timeout = time.Duration(override.TimeoutSec) * time.Second
+		}
+		if override.MemoryLimitKb > 0 {
+			memoryLimitKb = override.MemoryLimitKb
+		}
+	}
+	return timeout, memoryLimitKb
+}
+
+// joinNonEmpty nối các đoạn text khác rỗng, mỗi đoạn cách nhau một dòng trống.
+func joinNonEmpty(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
 }
 
 // publishOverallError gửi một lỗi chung cho tất cả test cases của một submission
-// (Dùng khi có lỗi ở giai đoạn chuẩn bị, trước khi chạy từng test case)
-func (r *Runner) publishOverallError(submissionID string, status models.TestcaseStatus, errMsg string) {
-	// Cần danh sách TestCase IDs để gửi lỗi. Nếu không có, gửi một bản tin chung.
-	// Hoặc, API của bạn cần đảm bảo submission.TestCases không rỗng.
-	// For now, assuming we don't have test case IDs if this function is called very early.
-	// A better approach might be to have a dedicated NATS subject for submission-level errors.
-	// Here, we'll just log it. The client consuming results should handle missing test case results.
-	log.Printf("Publishing overall error for SubmissionID %s: Status=%s, Error=%s", submissionID, status, errMsg)
-	// Nếu bạn vẫn muốn publish cho từng test case (nếu có thông tin):
-	// for _, tc := range submission.TestCases { // Cần `submission` ở đây
-	// 	result := models.SubmissionResult{
-	// 		SubmissionID: submissionID,
-	// 		TestCaseID:   tc.ID,
-	// 		Status:       status,
-	// 		Error:        errMsg,
-	// 	}
-	// 	r.natsPublisher.PublishSubmissionResult(result)
-	// }
+// (dùng khi có lỗi ở giai đoạn chuẩn bị, trước khi chạy từng test case) và đặt verdict của
+// message tổng kết, để submission không có test case nào vẫn có kết quả.
+func (r *Runner) publishOverallError(tracker *resultTracker, submission models.Submission, status models.TestcaseStatus, errMsg string) {
+	log.Printf("Publishing overall error for SubmissionID %s: Status=%s, Error=%s", submission.ID, status, errMsg)
+	tracker.fail(status, errMsg)
+	for _, tc := range submission.TestCases {
+		tracker.publish(models.SubmissionResult{
+			SubmissionID: submission.ID,
+			TestCaseID:   tc.ID,
+			Status:       status,
+			Error:        errMsg,
+		})
+	}
+}
+
+// publishCancelled báo các test case chưa có kết quả là Cancelled và đặt verdict của submission.
+func (r *Runner) publishCancelled(tracker *resultTracker, submission models.Submission, testCases []models.TestCase, compileCacheStatus, reason string) {
+	tracker.cancel(reason)
+	for _, tc := range testCases {
+		tracker.publish(models.SubmissionResult{
+			SubmissionID: submission.ID,
+			TestCaseID:   tc.ID,
+			Status:       models.Cancelled,
+			Error:        reason,
+			CompileCache: compileCacheStatus,
+		})
+	}
+}
+
+// publishDeadLetter chuyển submission bị từ chối vào dead-letter, để có thể gửi lại sau khi sửa
+// cấu hình của runner (ví dụ thêm ngôn ngữ). Payload là message gốc nhận từ NATS; submission không đến
+// từ NATS (không có Raw) thì được encode lại.
+func (r *Runner) publishDeadLetter(submission models.Submission, reason error) {
+	payload := submission.Raw
+	if payload == nil {
+		var err error
+		if payload, err = json.Marshal(submission); err != nil {
+			log.Printf("Error marshalling rejected SubmissionID %s for dead-letter: %v", submission.ID, err)
+			return
+		}
+	}
+	r.natsPublisher.PublishDeadLetter(models.DeadLetter{
+		SubmissionID: submission.ID,
+		Stage:        models.DeadLetterValidation,
+		Reason:       reason.Error(),
+		Subject:      r.natsPublisher.Subjects().SubmissionCreated,
+		Payload:      payload,
+	})
 }
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/core/sandbox"
+	"github.com/Mirai3103/remote-compiler/internal/language"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// summaryReporter giữ lại summary của submission.
+type summaryReporter struct {
+	summary models.SubmissionSummary
+}
+
+func (r *summaryReporter) PublishSubmissionResult(models.SubmissionResult) error { return nil }
+func (r *summaryReporter) PublishSubmissionScore(models.SubmissionScore) error   { return nil }
+func (r *summaryReporter) PublishSubmissionSummary(summary models.SubmissionSummary) error {
+	r.summary = summary
+	return nil
+}
+func (r *summaryReporter) PublishSubmissionProgress(models.SubmissionProgress) {}
+
+// TestProcessRejectsUnsafeID kiểm tra submission ID trỏ ra ngoài sandboxBaseDir bị từ chối trước khi
+// runner tạo hay xóa thư mục tạm.
+func TestProcessRejectsUnsafeID(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(root, "outside")
+	if err := os.Mkdir(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rc := config.RunnerConfig{SandboxType: "direct", SandboxBaseDir: filepath.Join(root, "sandbox")}
+	executor, err := sandbox.NewExecutor(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	languages, err := language.NewRegistry([]config.LanguageConfig{{ID: "python", SourceFile: "main.py", RunCommand: "python3 {source_file}"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := NewRunner(executor, nil, &rc, languages)
+
+	for _, id := range []string{"../outside", "", "a/../../outside"} {
+		reporter := &summaryReporter{}
+		runner.ProcessSubmissionWith(context.Background(), models.Submission{
+			ID:         id,
+			LanguageID: "python",
+			Code:       "print(1)",
+			TestCases:  []models.TestCase{{ID: "1", ExpectOutput: "1"}},
+		}, reporter)
+		if reporter.summary.Verdict != models.Rejected {
+			t.Errorf("SubmissionID %q: verdict = %q, want %q", id, reporter.summary.Verdict, models.Rejected)
+		}
+		if _, err := os.Stat(outside); err != nil {
+			t.Fatalf("SubmissionID %q: directory outside sandboxBaseDir was removed: %v", id, err)
+		}
+	}
+}
+
+func TestUnknownCompileLimits(t *testing.T) {
+	limits := map[string]config.CompileLimitConfig{
+		"java":   {TimeoutSec: 90},
+		"kotlin": {TimeoutSec: 120},
+		"rust":   {TimeoutSec: 120},
+		"cpp":    {MemoryLimitKb: 524288},
+	}
+	got := unknownCompileLimits(limits, []string{"CPP", "java", "python"})
+	if want := []string{"kotlin", "rust"}; !slices.Equal(got, want) {
+		t.Errorf("unknownCompileLimits() = %v, want %v", got, want)
+	}
+	if got := unknownCompileLimits(nil, []string{"java"}); len(got) != 0 {
+		t.Errorf("unknownCompileLimits(nil) = %v, want none", got)
+	}
+}
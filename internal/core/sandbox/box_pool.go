@@ -0,0 +1,115 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// DefaultBoxIDMin is the first isolate box ID shared by all runner instances on a host.
+	DefaultBoxIDMin = 0
+	// DefaultBoxIDMax is the last isolate box ID shared by all runner instances on a host.
+	DefaultBoxIDMax = 999
+)
+
+// boxPool leases isolate box IDs from a fixed range [first, last].
+// A box ID is held by exactly one Execute call at a time, so the number of
+// concurrently running boxes is bounded by the size of the range.
+type boxPool struct {
+	first int
+	last  int
+	free  chan int      // IDs that are currently available
+	group chan struct{} // Held while AcquireN collects its IDs
+}
+
+// newBoxPool creates a pool holding every ID in [first, last].
+func newBoxPool(first, last int) (*boxPool, error) {
+	if first < 0 || last < first {
+		return nil, fmt.Errorf("invalid isolate box ID range [%d, %d]", first, last)
+	}
+	p := &boxPool{
+		first: first,
+		last:  last,
+		free:  make(chan int, last-first+1),
+		group: make(chan struct{}, 1),
+	}
+	for id := first; id <= last; id++ {
+		p.free <- id
+	}
+	return p, nil
+}
+
+// partitionBoxRange splits [min, max] into count equal partitions and returns
+// the partition owned by the instance with the given index, so several runner
+// processes on the same host never share a box ID.
+func partitionBoxRange(min, max, index, count int) (first, last int, err error) {
+	if count <= 1 {
+		return min, max, nil
+	}
+	if index < 0 || index >= count {
+		return 0, 0, fmt.Errorf("instance index %d is out of range for %d instances", index, count)
+	}
+	size := (max - min + 1) / count
+	if size == 0 {
+		return 0, 0, fmt.Errorf("box ID range [%d, %d] is too small for %d instances", min, max, count)
+	}
+	first = min + index*size
+	last = first + size - 1
+	return first, last, nil
+}
+
+// Acquire leases a free box ID, blocking until one is available or ctx is done.
+func (p *boxPool) Acquire(ctx context.Context) (int, error) {
+	select {
+	case id := <-p.free:
+		return id, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// AcquireN leases n box IDs together, blocking until all of them are available or ctx is done.
+// Only one AcquireN collects IDs at a time, so two callers each holding part of what they
+// need can never wait for each other; single Acquire calls hold one box and always finish.
+func (p *boxPool) AcquireN(ctx context.Context, n int) ([]int, error) {
+	if n > p.Size() {
+		return nil, fmt.Errorf("cannot lease %d boxes from a pool of %d", n, p.Size())
+	}
+	select {
+	case p.group <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-p.group }()
+	ids := make([]int, 0, n)
+	for len(ids) < n {
+		id, err := p.Acquire(ctx)
+		if err != nil {
+			for _, id := range ids {
+				p.Release(id)
+			}
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Release returns a box ID to the pool. It must only be called after the box was cleaned up.
+func (p *boxPool) Release(id int) {
+	p.free <- id
+}
+
+// Size returns the total number of box IDs managed by the pool.
+func (p *boxPool) Size() int {
+	return p.last - p.first + 1
+}
+
+// IDs returns every box ID managed by the pool.
+func (p *boxPool) IDs() []int {
+	ids := make([]int, 0, p.Size())
+	for id := p.first; id <= p.last; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,106 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// cgroupRemoveTimeout bounds how long removing a sandbox cgroup waits for its killed processes to exit.
+const cgroupRemoveTimeout = 2 * time.Second
+
+// enablePidsController checks that parent is a cgroup v2 directory offering the pids controller,
+// creating it if needed, and enables that controller for its child cgroups.
+func enablePidsController(parent string) error {
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return fmt.Errorf("cannot create cgroup %s: %w", parent, err)
+	}
+	controllers, err := os.ReadFile(filepath.Join(parent, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("%s is not a cgroup v2 directory: %w", parent, err)
+	}
+	if !slices.Contains(strings.Fields(string(controllers)), "pids") {
+		return fmt.Errorf("the pids controller is not available in cgroup %s (enable it in the parent's cgroup.subtree_control)", parent)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+pids"), 0); err != nil {
+		return fmt.Errorf("cannot enable the pids controller for children of cgroup %s: %w", parent, err)
+	}
+	return nil
+}
+
+// pidsCgroup is a cgroup v2 that limits the number of processes of a single sandbox run.
+// Unlike RLIMIT_NPROC, which counts every process of the user (the runner and all other
+// sandboxes included), pids.max only counts the processes started inside this cgroup.
+type pidsCgroup struct {
+	dir string
+	fd  *os.File // Passed to the child through SysProcAttr.CgroupFD
+}
+
+// newPidsCgroup creates a child cgroup of parent with pids.max set to maxProcesses.
+func newPidsCgroup(parent string, maxProcesses int) (*pidsCgroup, error) {
+	dir, err := os.MkdirTemp(parent, "sandbox-*")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create sandbox cgroup: %w", err)
+	}
+	c := &pidsCgroup{dir: dir}
+	if err := os.WriteFile(filepath.Join(dir, "pids.max"), []byte(strconv.Itoa(maxProcesses)), 0); err != nil {
+		c.remove()
+		return nil, fmt.Errorf("cannot set pids.max of cgroup %s: %w", dir, err)
+	}
+	if c.fd, err = os.Open(dir); err != nil {
+		c.remove()
+		return nil, fmt.Errorf("cannot open cgroup %s: %w", dir, err)
+	}
+	return c, nil
+}
+
+// attach makes the command start directly inside the cgroup, so no process can fork before being limited.
+func (c *pidsCgroup) attach(attr *syscall.SysProcAttr) {
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(c.fd.Fd())
+}
+
+// remove kills every process left in the cgroup (including ones that left the sandbox's
+// process group) and removes the cgroup.
+func (c *pidsCgroup) remove() {
+	if c.fd != nil {
+		c.fd.Close()
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, "cgroup.kill"), []byte("1"), 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+		c.killProcs() // Kernel older than 5.14 has no cgroup.kill
+	}
+	deadline := time.Now().Add(cgroupRemoveTimeout)
+	for {
+		err := syscall.Rmdir(c.dir)
+		if err == nil || errors.Is(err, syscall.ENOENT) {
+			return
+		}
+		// EBUSY until the killed processes have exited.
+		if !errors.Is(err, syscall.EBUSY) || time.Now().After(deadline) {
+			log.Printf("Cannot remove sandbox cgroup %s: %v", c.dir, err)
+			return
+		}
+		c.killProcs()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// killProcs sends SIGKILL to every process listed in cgroup.procs.
+func (c *pidsCgroup) killProcs() {
+	procs, err := os.ReadFile(filepath.Join(c.dir, "cgroup.procs"))
+	if err != nil {
+		return
+	}
+	for _, field := range strings.Fields(string(procs)) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+}
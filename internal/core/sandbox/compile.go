@@ -0,0 +1,65 @@
+package sandbox
+
+import (
+	"context"
+	"log"
+
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// compileTestCaseID is used as TestCaseID when a compile step goes through Execute.
+const compileTestCaseID = "compile"
+
+// compileWithExecutor runs a compile step through e.Execute, so the compiler gets
+// exactly the same isolation as the user's program, and maps the execution status
+// to a compile verdict.
+func compileWithExecutor(ctx context.Context, e Executor, req CompileRequest) (*CompileResult, error) {
+	log.Printf("[%s] Compiling SubmissionID: %s, Command: %v, TimeLimit: %dms, MemoryLimit: %dkB",
+		e.ID(), req.SubmissionID, req.CompileCommand, req.TimeLimitMs, req.MemoryLimitKb)
+	if len(req.CompileCommand) == 0 {
+		return nil, &Error{Type: ErrInternal, Message: "empty compile command"}
+	}
+
+	execResult, err := e.Execute(ctx, RunRequest{
+		SubmissionID:     req.SubmissionID,
+		TestCaseID:       compileTestCaseID,
+		RunCommand:       req.CompileCommand,
+		WorkingDirectory: req.WorkingDirectory,
+		TimeLimitMs:      req.TimeLimitMs,
+		MemoryLimitKb:    req.MemoryLimitKb,
+		FileSizeLimitKb:  req.FileSizeLimitKb,
+		MaxProcesses:     req.MaxProcesses,
+		Env:              req.Env,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CompileResult{
+		Output:       truncateOutput(execResult.Stdout+execResult.Stderr, req.MaxOutputBytes),
+		ExitCode:     execResult.ExitCode,
+		TimeUsedMs:   execResult.TimeUsedMs,
+		MemoryUsedKb: execResult.MemoryUsedKb,
+	}
+	switch execResult.Status {
+	case models.Success:
+		result.Status = models.Success
+	case models.TimeLimitExceeded:
+		result.Status = models.CompileTimeout
+	case models.MemoryLimitExceeded:
+		result.Status = models.CompileMemoryLimitExceeded
+	default:
+		result.Status = models.CompileError
+	}
+	log.Printf("[%s] Compilation finished for SubmissionID: %s. Status: %s, Time: %dms, Mem: %dkB",
+		e.ID(), req.SubmissionID, result.Status, result.TimeUsedMs, result.MemoryUsedKb)
+	return result, nil
+}
+
+// truncateOutput cuts s to at most limit bytes; limit <= 0 means no limit.
+func truncateOutput(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "\n... (output truncated)"
+}
@@ -5,8 +5,8 @@ import (
 	"context"
 	"fmt" // Thêm vào để format lỗi memory
 	"log"
+	"os"
 	"os/exec"
-	"strings"
 	"sync/atomic" // Sử dụng cho maxMemUsage
 	"syscall"
 	"time"
@@ -31,6 +31,17 @@ func (e *directExecutor) ID() string {
 	return "direct_executor_v1_mem_monitored" // Cập nhật ID nếu muốn
 }
 
+// ResolveExecutable looks name up in the runner's own PATH, since commands run directly on the host.
+func (e *directExecutor) ResolveExecutable(name string) (string, error) {
+	return lookPathIn(name, os.Getenv("PATH"), sameHostPath)
+}
+
+// Compile chạy lệnh biên dịch trực tiếp trên host.
+// Giới hạn FileSizeLimitKb và MaxProcesses không được áp dụng ở chế độ này.
+func (e *directExecutor) Compile(ctx context.Context, req CompileRequest) (*CompileResult, error) {
+	return compileWithExecutor(ctx, e, req)
+}
+
 // Execute chạy lệnh được cung cấp trực tiếp trên host, có theo dõi bộ nhớ.
 func (e *directExecutor) Execute(ctx context.Context, req RunRequest) (*ExecuteResult, error) {
 	log.Printf("[%s] DirectExecute: Starting execution for SubmissionID: %s, TestCaseID: %s, Command: %v, TimeLimit: %dms, MemoryLimit: %dkB",
@@ -39,9 +50,12 @@ func (e *directExecutor) Execute(ctx context.Context, req RunRequest) (*ExecuteR
 	cmd := exec.CommandContext(ctx, req.RunCommand[0], req.RunCommand[1:]...)
 	cmd.Dir = req.WorkingDirectory
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
+	cmd.Stdout = req.stdout(&stdout)
 	cmd.Stderr = &stderr
-	cmd.Stdin = strings.NewReader(req.Input)
+	cmd.Stdin = req.stdin()
+	if len(req.Env) > 0 {
+		cmd.Env = append(os.Environ(), req.Env...)
+	}
 
 	startTime := time.Now()
 	var execErr error
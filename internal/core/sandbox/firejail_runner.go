@@ -1 +1,313 @@
 package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const (
+	// DefaultFirejailPath is the default path to the firejail executable.
+	DefaultFirejailPath = "firejail"
+	// DefaultFirejailExtraTimeSeconds is added to the time limit for firejail's own --timeout.
+	DefaultFirejailExtraTimeSeconds = 1.0
+	// DefaultRlimitAsFactor multiplies the memory limit to get the address space rlimit.
+	DefaultRlimitAsFactor = 4.0
+	// DefaultFirejailCgroupParent is the cgroup v2 under which each run gets its own pids cgroup.
+	DefaultFirejailCgroupParent = "/sys/fs/cgroup/remote-compiler-firejail"
+	// firejailProcessName is skipped when summing the memory of the sandboxed process tree.
+	firejailProcessName = "firejail"
+	// firejailOwnProcesses is added to pids.max for firejail's own processes, which run in the same cgroup.
+	firejailOwnProcesses = 2
+)
+
+// firejailExecutor implements the sandbox.Executor interface using firejail.
+// It does not need a setuid helper other than firejail itself, and memory and
+// time are measured the same way as in directExecutor. The number of processes
+// is limited per run with a pids cgroup under CgroupParent, since firejail's
+// --rlimit-nproc counts every process of the user, including other sandboxes.
+//
+// The working directory is mounted as the private home directory of the sandbox
+// (--private), so host paths under WorkingDirectory in RunCommand are rewritten
+// to the matching path under the home directory.
+type firejailExecutor struct {
+	cfg     config.RunnerConfig
+	fjCfg   config.FirejailConfig
+	homeDir string // Home directory seen inside the sandbox
+}
+
+// newFirejailExecutor creates a new firejailExecutor, filling unset values with defaults.
+func newFirejailExecutor(rc config.RunnerConfig) (*firejailExecutor, error) {
+	fjCfg := rc.Firejail
+	if fjCfg.FirejailPath == "" {
+		fjCfg.FirejailPath = DefaultFirejailPath
+	}
+	if fjCfg.MaxProcesses == 0 {
+		fjCfg.MaxProcesses = DefaultProcesses
+	}
+	if fjCfg.FsizeKb == 0 {
+		fjCfg.FsizeKb = DefaultFsizeKb
+	}
+	if fjCfg.RlimitAsFactor <= 0 {
+		fjCfg.RlimitAsFactor = DefaultRlimitAsFactor
+	}
+	if fjCfg.ExtraTimeSeconds == 0 {
+		fjCfg.ExtraTimeSeconds = DefaultFirejailExtraTimeSeconds
+	}
+	if fjCfg.CgroupParent == "" {
+		fjCfg.CgroupParent = DefaultFirejailCgroupParent
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine home directory for firejail --private: %w", err)
+	}
+	return &firejailExecutor{
+		cfg:     rc,
+		fjCfg:   fjCfg,
+		homeDir: homeDir,
+	}, nil
+}
+
+// Validate checks that the firejail binary can be found and, unless the process
+// limit is disabled, that per-run pids cgroups can be created under CgroupParent.
+func (e *firejailExecutor) Validate() error {
+	if _, err := exec.LookPath(e.fjCfg.FirejailPath); err != nil {
+		return fmt.Errorf("firejail binary %q not found: %w", e.fjCfg.FirejailPath, err)
+	}
+	if e.fjCfg.MaxProcesses > 0 {
+		if err := enablePidsController(e.fjCfg.CgroupParent); err != nil {
+			return fmt.Errorf("firejail process limit: %w", err)
+		}
+	}
+	return nil
+}
+
+// ID returns the identifier for this executor.
+func (e *firejailExecutor) ID() string {
+	return "firejail_executor_v1"
+}
+
+// ResolveExecutable looks name up in the runner's PATH: firejail passes the environment
+// through and only makes the home directory private, so system paths are the host's.
+func (e *firejailExecutor) ResolveExecutable(name string) (string, error) {
+	return lookPathIn(name, os.Getenv("PATH"), sameHostPath)
+}
+
+// Compile runs the compile command inside firejail.
+func (e *firejailExecutor) Compile(ctx context.Context, req CompileRequest) (*CompileResult, error) {
+	return compileWithExecutor(ctx, e, req)
+}
+
+// Execute runs the command specified in RunRequest inside firejail, monitoring
+// the memory of the sandboxed process tree.
+func (e *firejailExecutor) Execute(ctx context.Context, req RunRequest) (*ExecuteResult, error) {
+	log.Printf("[%s] Starting execution for SubmissionID: %s, TestCaseID: %s, Command: %v, TimeLimit: %dms, MemoryLimit: %dkB",
+		e.ID(), req.SubmissionID, req.TestCaseID, req.RunCommand, req.TimeLimitMs, req.MemoryLimitKb)
+	if len(req.RunCommand) == 0 {
+		return nil, &Error{Type: ErrInternal, Message: "empty run command"}
+	}
+
+	args := e.buildArgs(req)
+	cmd := exec.Command(e.fjCfg.FirejailPath, args...)
+	cmd.Dir = req.WorkingDirectory
+	// Own process group so the whole sandbox can be killed at once.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if maxProcesses := e.maxProcesses(req); maxProcesses > 0 {
+		cgroup, err := newPidsCgroup(e.fjCfg.CgroupParent, maxProcesses+firejailOwnProcesses)
+		if err != nil {
+			return nil, &Error{Type: ErrInternal, Message: "failed to limit sandbox processes", Cause: err}
+		}
+		defer cgroup.remove()
+		cgroup.attach(cmd.SysProcAttr)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = req.stdout(&stdout)
+	cmd.Stderr = &stderr
+	cmd.Stdin = req.stdin()
+
+	startTime := time.Now()
+	if err := cmd.Start(); err != nil {
+		log.Printf("[%s] Failed to start firejail for TestCaseID %s: %v", e.ID(), req.TestCaseID, err)
+		return nil, &Error{Type: ErrCmdStart, Message: "failed to start firejail", Cause: err}
+	}
+	pid := int32(cmd.Process.Pid)
+	killSandbox := func() {
+		// Negative pid: signal the whole process group.
+		if err := syscall.Kill(-int(pid), syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+			log.Printf("[%s] Failed to kill sandbox process group %d for TestCaseID %s: %v", e.ID(), pid, req.TestCaseID, err)
+		}
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- cmd.Wait()
+	}()
+
+	var maxMemUsage uint64
+	var memoryLimitExceeded atomic.Bool
+	monitorCtx, monitorCancel := context.WithCancel(context.Background())
+	defer monitorCancel()
+	go func() {
+		ticker := time.NewTicker(memoryPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-monitorCtx.Done():
+				return
+			case <-ticker.C:
+				currentMem := processTreeRSS(pid, firejailProcessName)
+				if currentMem > atomic.LoadUint64(&maxMemUsage) {
+					atomic.StoreUint64(&maxMemUsage, currentMem)
+				}
+				if req.MemoryLimitKb > 0 && currentMem/1024 > uint64(req.MemoryLimitKb) {
+					log.Printf("[%s] Memory limit exceeded for TestCaseID %s. Usage: %d KB, Limit: %d KB",
+						e.ID(), req.TestCaseID, currentMem/1024, req.MemoryLimitKb)
+					memoryLimitExceeded.Store(true)
+					killSandbox()
+					return
+				}
+			}
+		}
+	}()
+
+	var waitErr error
+	timedOut := false
+	select {
+	case waitErr = <-errChan:
+	case <-ctx.Done():
+		timedOut = true
+		killSandbox()
+		waitErr = <-errChan
+		log.Printf("[%s] Context done for TestCaseID %s: %v. Error from Wait: %v", e.ID(), req.TestCaseID, ctx.Err(), waitErr)
+	}
+	monitorCancel()
+	timeUsedMs := int(time.Since(startTime).Milliseconds())
+
+	exitCode := 0
+	if waitErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(waitErr, &exitErr) {
+			log.Printf("[%s] cmd.Wait() error for TestCaseID %s (not ExitError): %v", e.ID(), req.TestCaseID, waitErr)
+			return nil, &Error{Type: ErrCmdWait, Message: "firejail wait failed with unexpected error", Cause: waitErr}
+		}
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			exitCode = ws.ExitStatus()
+		} else {
+			exitCode = -1
+		}
+	}
+
+	result := &ExecuteResult{
+		Stdout:       stdout.String(),
+		Stderr:       stderr.String(),
+		ExitCode:     exitCode,
+		TimeUsedMs:   timeUsedMs,
+		MemoryUsedKb: int(atomic.LoadUint64(&maxMemUsage) / 1024),
+	}
+	switch {
+	case memoryLimitExceeded.Load():
+		result.Status = models.MemoryLimitExceeded
+	case timedOut || (req.TimeLimitMs > 0 && timeUsedMs > req.TimeLimitMs):
+		result.Status = models.TimeLimitExceeded
+	case exitCode != 0:
+		result.Status = models.RuntimeError
+	default:
+		result.Status = models.Success
+	}
+
+	log.Printf("[%s] Finished execution for TestCaseID %s. Status: %s, Time: %dms, Mem: %dkB",
+		e.ID(), req.TestCaseID, result.Status, result.TimeUsedMs, result.MemoryUsedKb)
+	return result, nil
+}
+
+// maxProcesses returns the process limit of a run; 0 or less means no limit.
+func (e *firejailExecutor) maxProcesses(req RunRequest) int {
+	if req.MaxProcesses > 0 {
+		return req.MaxProcesses
+	}
+	return e.fjCfg.MaxProcesses
+}
+
+// buildArgs returns the firejail arguments for a run, followed by the rewritten command.
+func (e *firejailExecutor) buildArgs(req RunRequest) []string {
+	fsizeKb := e.fjCfg.FsizeKb
+	if req.FileSizeLimitKb > 0 {
+		fsizeKb = req.FileSizeLimitKb
+	}
+	args := []string{
+		"--quiet",
+		"--noprofile",
+		"--private=" + req.WorkingDirectory,
+		"--private-tmp",
+		"--private-dev",
+		"--net=none",
+		"--nonewprivs",
+		"--caps.drop=all",
+		"--nogroups",
+		fmt.Sprintf("--rlimit-fsize=%d", fsizeKb*1024),
+	}
+	for _, env := range req.Env {
+		args = append(args, "--env="+rebasePath(env, req.WorkingDirectory, e.homeDir))
+	}
+	if req.MemoryLimitKb > 0 {
+		asBytes := uint64(float64(req.MemoryLimitKb) * 1024 * e.fjCfg.RlimitAsFactor)
+		args = append(args, fmt.Sprintf("--rlimit-as=%d", asBytes))
+	}
+	if req.TimeLimitMs > 0 {
+		// Backstop in case the runner itself dies; the context handles the normal timeout.
+		timeoutSec := int(math.Ceil(float64(req.TimeLimitMs)/1000.0 + e.fjCfg.ExtraTimeSeconds))
+		args = append(args, fmt.Sprintf("--timeout=%02d:%02d:%02d", timeoutSec/3600, (timeoutSec/60)%60, timeoutSec%60))
+	}
+	args = append(args, e.fjCfg.ExtraArgs...)
+	args = append(args, "--")
+	for _, part := range req.RunCommand {
+		args = append(args, rebasePath(part, req.WorkingDirectory, e.homeDir))
+	}
+	return args
+}
+
+// rebasePath rewrites a path under from (or an argument containing it) to the same path under to.
+func rebasePath(arg, from, to string) string {
+	from = filepath.Clean(from)
+	if arg == from {
+		return to
+	}
+	return strings.ReplaceAll(arg, from+string(filepath.Separator), to+string(filepath.Separator))
+}
+
+// processTreeRSS returns the summed RSS (bytes) of pid and all of its descendants,
+// skipping processes named skipName (the sandbox helpers themselves).
+func processTreeRSS(pid int32, skipName string) uint64 {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return 0 // Process có thể đã kết thúc
+	}
+	var total uint64
+	if name, err := proc.Name(); err != nil || name != skipName {
+		if memInfo, err := proc.MemoryInfo(); err == nil {
+			total += memInfo.RSS
+		}
+	}
+	children, err := proc.Children()
+	if err != nil {
+		return total
+	}
+	for _, child := range children {
+		total += processTreeRSS(child.Pid, skipName)
+	}
+	return total
+}
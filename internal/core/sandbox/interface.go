@@ -1,7 +1,12 @@
 package sandbox
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"strings"
+
 	"github.com/Mirai3103/remote-compiler/internal/config"
 
 	"github.com/Mirai3103/remote-compiler/internal/models"
@@ -13,6 +18,7 @@ const (
 	DirectSandbox   Type = "direct"   // Không sử dụng sandbox, chạy trực tiếp
 	FirejailSandbox Type = "firejail" // Sử dụng firejail để cách ly
 	IsolateSandbox  Type = "isolate"  // Sử dụng isolate để cách ly
+	NsjailSandbox   Type = "nsjail"   // Sử dụng nsjail (namespaces + cgroups) để cách ly
 )
 
 // RunRequest chứa thông tin cần thiết để *chạy* một chương trình đã được chuẩn bị
@@ -25,8 +31,52 @@ type RunRequest struct {
 	Input            string   // Dữ liệu đầu vào cho test case
 	TimeLimitMs      int      // Giới hạn thời gian chạy (milliseconds)
 	MemoryLimitKb    int      // Giới hạn bộ nhớ (kilobytes)
-	// Có thể thêm các thông tin khác như Environment Variables nếu cần
-	// EnvVars          map[string]string
+	FileSizeLimitKb  int      // Giới hạn kích thước file được ghi ra (kilobytes); 0 = mặc định của executor
+	MaxProcesses     int      // Số process/thread tối đa; 0 = mặc định của executor
+	Env              []string // Biến môi trường bổ sung dạng "KEY=VALUE"
+	// Stdin/Stdout, nếu khác nil, thay cho Input và việc thu stdout vào ExecuteResult.Stdout
+	// (bài tương tác: stdin/stdout của chương trình được nối với interactor). Nên dùng *os.File
+	// (ví dụ đầu của os.Pipe) để tiến trình nhận trực tiếp file descriptor.
+	Stdin  io.Reader
+	Stdout io.Writer
+}
+
+// stdin trả về stdin cho tiến trình: req.Stdin nếu có, nếu không thì req.Input.
+func (req RunRequest) stdin() io.Reader {
+	if req.Stdin != nil {
+		return req.Stdin
+	}
+	return strings.NewReader(req.Input)
+}
+
+// stdout trả về nơi ghi stdout của tiến trình: req.Stdout nếu có, nếu không thì buf.
+func (req RunRequest) stdout(buf *bytes.Buffer) io.Writer {
+	if req.Stdout != nil {
+		return req.Stdout
+	}
+	return buf
+}
+
+// CompileRequest chứa thông tin để chạy bước biên dịch bên trong sandbox.
+type CompileRequest struct {
+	SubmissionID     string   // ID của submission
+	CompileCommand   []string // Lệnh biên dịch đã được thay placeholder
+	WorkingDirectory string   // Thư mục chứa source code, cũng là nơi ghi file output
+	TimeLimitMs      int      // Giới hạn thời gian biên dịch (milliseconds)
+	MemoryLimitKb    int      // Giới hạn bộ nhớ cho trình biên dịch (kilobytes)
+	FileSizeLimitKb  int      // Giới hạn kích thước file output (kilobytes)
+	MaxProcesses     int      // Số process/thread tối đa cho trình biên dịch
+	MaxOutputBytes   int      // Độ dài tối đa của log biên dịch trả về; 0 = không giới hạn
+	Env              []string // Biến môi trường bổ sung dạng "KEY=VALUE"
+}
+
+// CompileResult chứa kết quả của bước biên dịch.
+type CompileResult struct {
+	Status       models.TestcaseStatus // Success, CompileError, CompileTimeout hoặc CompileMemoryLimitExceeded
+	Output       string                // stdout + stderr của trình biên dịch (đã cắt theo MaxOutputBytes)
+	ExitCode     int                   // Mã thoát của trình biên dịch
+	TimeUsedMs   int                   // Thời gian biên dịch (milliseconds)
+	MemoryUsedKb int                   // Bộ nhớ sử dụng (kilobytes)
 }
 
 // ExecuteResult chứa kết quả sau khi thực thi code.
@@ -42,27 +92,73 @@ type ExecuteResult struct {
 	// SandboxError   string             // (Tùy chọn) Lỗi từ chính sandbox nếu có, phân biệt với lỗi của code người dùng
 }
 
+// Compiler chạy bước biên dịch bên trong sandbox, với giới hạn riêng cho việc biên dịch.
+type Compiler interface {
+	// Compile chạy lệnh biên dịch trong CompileRequest. Lỗi trả về là lỗi của sandbox,
+	// còn lỗi biên dịch của code người dùng nằm trong CompileResult.Status.
+	Compile(ctx context.Context, req CompileRequest) (*CompileResult, error)
+}
+
 // Executor là interface chung cho các môi trường thực thi code đã được chuẩn bị.
 type Executor interface {
+	Compiler
+
 	// Execute chạy lệnh được cung cấp trong RunRequest bên trong môi trường sandbox.
 	// Nó không chịu trách nhiệm biên dịch.
 	Execute(ctx context.Context, req RunRequest) (*ExecuteResult, error)
 
 	// ID trả về một định danh cho loại executor này (ví dụ: "direct", "firejail_v1").
 	ID() string
+
+	// ResolveExecutable trả về đường dẫn trên host của file mà sandbox chạy cho lệnh name, tìm theo PATH
+	// và các mount của sandbox (không phải của runner). Dùng để nhận biết toolchain cho compile cache.
+	ResolveExecutable(name string) (string, error)
+}
+
+// Reserver được implement bởi executor có số sandbox giới hạn (isolate). Các Execute chạy đồng thời và
+// chờ nhau (interactor và chương trình của thí sinh) phải giữ trước sandbox cùng lúc, nếu không mỗi
+// lần chạy tương tác có thể chỉ giữ được một nửa và tất cả chờ nhau mãi mãi.
+type Reserver interface {
+	// Reserve giữ trước n sandbox và trả về context mang chúng: Execute với context đó (hoặc context con)
+	// dùng sandbox đã giữ thay vì chờ pool. release trả lại các sandbox chưa dùng và phải được gọi
+	// sau khi các Execute kết thúc.
+	Reserve(ctx context.Context, n int) (reserved context.Context, release func(), err error)
 }
 
-func NewExecutor(rc config.RunnerConfig) Executor {
+// NewExecutor tạo Executor tương ứng với rc.SandboxType và kiểm tra rằng
+// các binary cần thiết (isolate, nsjail) có sẵn trên host.
+func NewExecutor(rc config.RunnerConfig) (Executor, error) {
 	switch rc.SandboxType {
 	case string(DirectSandbox):
 		return &directExecutor{
 			cfg: rc,
-		}
+		}, nil
 	case string(FirejailSandbox):
-		return nil
+		executor, err := newFirejailExecutor(rc)
+		if err != nil {
+			return nil, err
+		}
+		if err := executor.Validate(); err != nil {
+			return nil, err
+		}
+		return executor, nil
 	case string(IsolateSandbox):
-		return nil
+		executor, err := NewIsolateExecutor(rc, rc.Isolate)
+		if err != nil {
+			return nil, err
+		}
+		if err := executor.Validate(); err != nil {
+			return nil, err
+		}
+		executor.CleanupStaleBoxes()
+		return executor, nil
+	case string(NsjailSandbox):
+		executor := newNsjailExecutor(rc)
+		if err := executor.Validate(); err != nil {
+			return nil, err
+		}
+		return executor, nil
 	default:
-		return nil
+		return nil, fmt.Errorf("unknown sandbox type %q", rc.SandboxType)
 	}
 }
@@ -13,7 +13,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"sync/atomic"
 	"time"
 )
 
@@ -32,32 +31,25 @@ const (
 	DefaultWallTimeFactor = 2.0
 	// DefaultBoxCleanupTimeout is the timeout for the isolate --cleanup command.
 	DefaultBoxCleanupTimeout = 5 * time.Second
+	// boxCleanupRetryInterval is the delay between retries when a box could not be cleaned up.
+	boxCleanupRetryInterval = 10 * time.Second
 )
 
 // IsolateExecutorConfig holds configuration specific to the IsolateExecutor.
-type IsolateExecutorConfig struct {
-	IsolatePath      string  `mapstructure:"isolatePath"`
-	EnvPath          string  `mapstructure:"envPath"`
-	DefaultFsizeKb   int     `mapstructure:"defaultFsizeKb"`
-	DefaultProcesses int     `mapstructure:"defaultProcesses"`
-	ExtraTimeSeconds float64 `mapstructure:"extraTimeSeconds"`
-	WallTimeFactor   float64 `mapstructure:"wallTimeFactor"`
-	// TempDir is a directory on the host for temporary files like stdin, stdout, meta.
-	// If empty, os.TempDir() will be used.
-	TempDir string `mapstructure:"tempDir"`
-}
+// It is loaded from the "runner.isolate" section of the configuration.
+type IsolateExecutorConfig = config.IsolateConfig
 
 // IsolateExecutor implements the sandbox.Executor interface using the 'isolate' tool.
 type IsolateExecutor struct {
 	config       IsolateExecutorConfig
 	runnerConfig config.RunnerConfig // General runner config for SandboxBaseDir etc.
-	boxIDCounter atomic.Uint32
+	pool         *boxPool            // Box IDs owned by this runner instance
 }
 
-// NewIsolateExecutor creates a new IsolateExecutor.
-// It's assumed that the relevant IsolateExecutorConfig is part of the global RunnerConfig
-// or passed appropriately. For this example, we'll assume it's nested or accessible.
-func NewIsolateExecutor(runnerCfg config.RunnerConfig, isolateCfg IsolateExecutorConfig) *IsolateExecutor {
+// NewIsolateExecutor creates a new IsolateExecutor, filling unset values with defaults.
+// The box ID range [BoxIDMin, BoxIDMax] is partitioned between InstanceCount runner
+// instances on the same host; this instance leases IDs from partition InstanceIndex.
+func NewIsolateExecutor(runnerCfg config.RunnerConfig, isolateCfg IsolateExecutorConfig) (*IsolateExecutor, error) {
 	if isolateCfg.IsolatePath == "" {
 		isolateCfg.IsolatePath = DefaultIsolatePath
 	}
@@ -76,11 +68,113 @@ func NewIsolateExecutor(runnerCfg config.RunnerConfig, isolateCfg IsolateExecuto
 	if isolateCfg.WallTimeFactor == 0 {
 		isolateCfg.WallTimeFactor = DefaultWallTimeFactor
 	}
+	if isolateCfg.BoxIDMin == 0 && isolateCfg.BoxIDMax == 0 {
+		isolateCfg.BoxIDMin = DefaultBoxIDMin
+		isolateCfg.BoxIDMax = DefaultBoxIDMax
+	}
+
+	first, last, err := partitionBoxRange(isolateCfg.BoxIDMin, isolateCfg.BoxIDMax, isolateCfg.InstanceIndex, isolateCfg.InstanceCount)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := newBoxPool(first, last)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[isolate] Using box IDs %d-%d (%d boxes) for instance %d/%d",
+		first, last, pool.Size(), isolateCfg.InstanceIndex, max(isolateCfg.InstanceCount, 1))
+	if runnerCfg.MaxConcurrentJobs > pool.Size() {
+		log.Printf("[isolate] Warning: maxConcurrentJobs (%d) is larger than the box pool (%d); jobs will wait for free boxes",
+			runnerCfg.MaxConcurrentJobs, pool.Size())
+	}
 
 	return &IsolateExecutor{
 		config:       isolateCfg,
 		runnerConfig: runnerCfg,
+		pool:         pool,
+	}, nil
+}
+
+// Validate checks that the isolate binary can be found and executed.
+func (e *IsolateExecutor) Validate() error {
+	path, err := exec.LookPath(e.config.IsolatePath)
+	if err != nil {
+		return fmt.Errorf("isolate binary %q not found: %w", e.config.IsolatePath, err)
+	}
+	if output, err := exec.Command(path, "--version").CombinedOutput(); err != nil {
+		return fmt.Errorf("isolate binary %q is not usable: %w (output: %s)", path, err, strings.TrimSpace(string(output)))
 	}
+	return nil
+}
+
+// CleanupStaleBoxes force-cleans every box owned by this instance. Boxes may be
+// left behind when a previous runner process crashed in the middle of a run.
+func (e *IsolateExecutor) CleanupStaleBoxes() {
+	cleaned := 0
+	for _, boxID := range e.pool.IDs() {
+		if err := e.cleanupBox(boxID); err != nil {
+			log.Printf("[%s] BoxID %d: Failed to clean up stale box: %v", e.ID(), boxID, err)
+			continue
+		}
+		cleaned++
+	}
+	log.Printf("[%s] Cleaned up %d/%d boxes on startup.", e.ID(), cleaned, e.pool.Size())
+}
+
+// initBox runs isolate --init for a box, retrying once after a forced cleanup
+// in case the box was left initialized.
+func (e *IsolateExecutor) initBox(boxID int) error {
+	initArgs := []string{fmt.Sprintf("--box-id=%d", boxID), "--cg", "--init"}
+	log.Printf("[%s] BoxID %d: Initializing sandbox: %s %v", e.ID(), boxID, e.config.IsolatePath, initArgs)
+	output, err := exec.Command(e.config.IsolatePath, initArgs...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	log.Printf("[%s] BoxID %d: Isolate init failed, cleaning up and retrying. Output: %s", e.ID(), boxID, string(output))
+	if cleanupErr := e.cleanupBox(boxID); cleanupErr != nil {
+		return &Error{Type: ErrInternal, Message: "isolate init failed", Cause: err, Details: string(output)}
+	}
+	if output, err = exec.Command(e.config.IsolatePath, initArgs...).CombinedOutput(); err != nil {
+		return &Error{Type: ErrInternal, Message: "isolate init failed", Cause: err, Details: string(output)}
+	}
+	return nil
+}
+
+// cleanupBox runs isolate --cleanup for a box.
+func (e *IsolateExecutor) cleanupBox(boxID int) error {
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), DefaultBoxCleanupTimeout)
+	defer cancel()
+	cleanupArgs := []string{fmt.Sprintf("--box-id=%d", boxID), "--cg", "--cleanup"}
+	output, err := exec.CommandContext(cleanupCtx, e.config.IsolatePath, cleanupArgs...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// releaseBox cleans up a box and returns it to the pool. A box that cannot be
+// cleaned up is kept out of the pool and retried in the background, so a dirty
+// box is never handed to another run.
+func (e *IsolateExecutor) releaseBox(boxID int) {
+	err := e.cleanupBox(boxID)
+	if err == nil {
+		log.Printf("[%s] BoxID %d: Sandbox cleanup successful.", e.ID(), boxID)
+		e.pool.Release(boxID)
+		return
+	}
+	log.Printf("[%s] BoxID %d: Isolate cleanup failed, box quarantined: %v", e.ID(), boxID, err)
+	go func() {
+		for {
+			time.Sleep(boxCleanupRetryInterval)
+			if err := e.cleanupBox(boxID); err != nil {
+				log.Printf("[%s] BoxID %d: Isolate cleanup retry failed: %v", e.ID(), boxID, err)
+				continue
+			}
+			log.Printf("[%s] BoxID %d: Quarantined box cleaned up, returning it to the pool.", e.ID(), boxID)
+			e.pool.Release(boxID)
+			return
+		}
+	}()
 }
 
 // ID returns the identifier for this executor.
@@ -88,15 +182,83 @@ func (e *IsolateExecutor) ID() string {
 	return "isolate_executor_v1"
 }
 
+// ResolveExecutable looks name up in EnvPath. isolate binds the host's system directories
+// at the same paths, so the file found there is the one the box runs.
+func (e *IsolateExecutor) ResolveExecutable(name string) (string, error) {
+	return lookPathIn(name, e.config.EnvPath, sameHostPath)
+}
+
+// boxReservation holds box IDs leased by Reserve that Execute has not taken yet.
+type boxReservation struct {
+	ids chan int
+}
+
+type boxReservationKey struct{}
+
+// Reserve leases n boxes at once; Execute calls with the returned context take one of them
+// instead of waiting for the pool. release returns the boxes no Execute has taken.
+func (e *IsolateExecutor) Reserve(ctx context.Context, n int) (context.Context, func(), error) {
+	ids, err := e.pool.AcquireN(ctx, n)
+	if err != nil {
+		return nil, nil, &Error{Type: ErrInternal, Message: fmt.Sprintf("cannot reserve %d isolate boxes", n), Cause: err}
+	}
+	res := &boxReservation{ids: make(chan int, n)}
+	for _, id := range ids {
+		res.ids <- id
+	}
+	release := func() {
+		for {
+			select {
+			case id := <-res.ids:
+				e.pool.Release(id) // Chưa được init, không cần cleanup
+			default:
+				return
+			}
+		}
+	}
+	return context.WithValue(ctx, boxReservationKey{}, res), release, nil
+}
+
+// acquireBox takes a box reserved for ctx if there is one left, otherwise leases one from the pool.
+func (e *IsolateExecutor) acquireBox(ctx context.Context) (int, error) {
+	if res, ok := ctx.Value(boxReservationKey{}).(*boxReservation); ok {
+		select {
+		case id := <-res.ids:
+			return id, nil
+		default:
+		}
+	}
+	return e.pool.Acquire(ctx)
+}
+
+// Compile runs the compile command within an isolate sandbox.
+func (e *IsolateExecutor) Compile(ctx context.Context, req CompileRequest) (*CompileResult, error) {
+	return compileWithExecutor(ctx, e, req)
+}
+
 // Execute runs the command specified in RunRequest within an isolate sandbox.
 func (e *IsolateExecutor) Execute(ctx context.Context, req RunRequest) (*ExecuteResult, error) {
-	boxID := e.boxIDCounter.Add(1)
-	boxIDStr := fmt.Sprintf("%d", boxID)
+	boxID, err := e.acquireBox(ctx)
+	if err != nil {
+		return nil, &Error{Type: ErrInternal, Message: "no isolate box available", Cause: err}
+	}
+	boxIDStr := strconv.Itoa(boxID)
 
 	log.Printf("[%s] BoxID %s: Starting execution for SubmissionID: %s, TestCaseID: %s",
 		e.ID(), boxIDStr, req.SubmissionID, req.TestCaseID)
 
-	// 1. Prepare temporary host files for stdin, stdout, stderr, meta
+	// 1. Isolate init
+	if err := e.initBox(boxID); err != nil {
+		e.releaseBox(boxID)
+		return nil, err
+	}
+
+	// 2. Defer Isolate cleanup; the box only goes back to the pool once it is clean.
+	defer e.releaseBox(boxID)
+
+	// 3. Prepare temporary host files for stdin, stdout, stderr, meta.
+	// Tên file stdout/stderr/meta chỉ phụ thuộc box ID, nên các defer xóa file được đăng ký sau releaseBox
+	// để chạy trước nó: file phải bị xóa trước khi box được trả về pool và lần chạy khác dùng lại tên này.
 	tempFileHostDir := e.config.TempDir
 	if tempFileHostDir == "" {
 		tempFileHostDir = os.TempDir()
@@ -121,33 +283,11 @@ func (e *IsolateExecutor) Execute(ctx context.Context, req RunRequest) (*Execute
 	defer os.Remove(stderrFilePath)
 	defer os.Remove(metaFilePath)
 
-	// 2. Isolate init
-	initArgs := []string{"--box-id=" + boxIDStr, "--cg", "--init"}
-	initCmd := exec.Command(e.config.IsolatePath, initArgs...)
-	log.Printf("[%s] BoxID %s: Initializing sandbox: %s %v", e.ID(), boxIDStr, e.config.IsolatePath, initArgs)
-	if output, err := initCmd.CombinedOutput(); err != nil {
-		log.Printf("[%s] BoxID %s: Isolate init failed. Output: %s", e.ID(), boxIDStr, string(output))
-		return nil, &Error{Type: ErrInternal, Message: "isolate init failed", Cause: err, Details: string(output)}
-	}
-
-	// 3. Defer Isolate cleanup
-	defer func() {
-		cleanupCtx, cancel := context.WithTimeout(context.Background(), DefaultBoxCleanupTimeout)
-		defer cancel()
-		cleanupArgs := []string{"--box-id=" + boxIDStr, "--cleanup"}
-		cleanupCmd := exec.CommandContext(cleanupCtx, e.config.IsolatePath, cleanupArgs...)
-		log.Printf("[%s] BoxID %s: Cleaning up sandbox: %s %v", e.ID(), boxIDStr, e.config.IsolatePath, cleanupArgs)
-		if output, err := cleanupCmd.CombinedOutput(); err != nil {
-			// Log cleanup error, but don't override original execution error
-			log.Printf("[%s] BoxID %s: Isolate cleanup failed. Output: %s, Error: %v", e.ID(), boxIDStr, string(output), err)
-		} else {
-			log.Printf("[%s] BoxID %s: Sandbox cleanup successful.", e.ID(), boxIDStr)
-		}
-	}()
-
 	// 4. Construct isolate run command arguments
 	runArgs := []string{"--box-id=" + boxIDStr, "--cg"}
-	runArgs = append(runArgs, "--cg-mem="+fmt.Sprintf("%d", req.MemoryLimitKb)) // Memory limit in KB
+	if req.MemoryLimitKb > 0 {
+		runArgs = append(runArgs, "--cg-mem="+fmt.Sprintf("%d", req.MemoryLimitKb)) // Memory limit in KB
+	}
 	timeLimitSec := float64(req.TimeLimitMs) / 1000.0
 	runArgs = append(runArgs, "--time="+fmt.Sprintf("%.3f", timeLimitSec)) // CPU time limit in seconds
 
@@ -159,26 +299,35 @@ func (e *IsolateExecutor) Execute(ctx context.Context, req RunRequest) (*Execute
 	runArgs = append(runArgs, "--extra-time="+fmt.Sprintf("%.3f", e.config.ExtraTimeSeconds))
 
 	fsizeKb := e.config.DefaultFsizeKb
-	if req.MemoryLimitKb > 0 && req.MemoryLimitKb*2 > fsizeKb { // Heuristic for fsize based on mem limit
-		// fsizeKb = req.MemoryLimitKb * 2 // Allow files up to 2x memory
+	if req.FileSizeLimitKb > 0 {
+		fsizeKb = req.FileSizeLimitKb
 	}
 	runArgs = append(runArgs, "--fsize="+fmt.Sprintf("%d", fsizeKb))
 
-	runArgs = append(runArgs, "--stdin="+stdinFile.Name())
-	runArgs = append(runArgs, "--stdout="+stdoutFilePath)
+	// Không có --stdin/--stdout thì isolate chuyển stdio của chính nó cho chương trình (bài tương tác).
+	if req.Stdin == nil {
+		runArgs = append(runArgs, "--stdin="+stdinFile.Name())
+	}
+	if req.Stdout == nil {
+		runArgs = append(runArgs, "--stdout="+stdoutFilePath)
+	}
 	runArgs = append(runArgs, "--stderr="+stderrFilePath)
 	runArgs = append(runArgs, "--meta="+metaFilePath)
 
-	// Mount the host's working directory (containing the code) to /box inside the sandbox.
-	// Isolate executes commands with /box as the default current directory.
-	runArgs = append(runArgs, "--dir="+req.WorkingDirectory+":/box:rw") // :rw for compilation/output if needed
-	// For compiled languages where req.WorkingDirectory might contain only the binary and it's read-only:
-	// runArgs = append(runArgs, "--dir="+req.WorkingDirectory+":/box:ro")
+	// Mount the host's working directory (containing the code) at the same path inside the sandbox,
+	// so host paths in RunCommand keep working, and run the command from there.
+	runArgs = append(runArgs, "--dir="+req.WorkingDirectory+"="+req.WorkingDirectory+":rw")
+	runArgs = append(runArgs, "--chdir="+req.WorkingDirectory)
 
 	runArgs = append(runArgs, "--env=PATH="+e.config.EnvPath)
-	// Add any other environment variables from req.EnvVars if that feature is added
+	for _, env := range req.Env {
+		runArgs = append(runArgs, "--env="+env)
+	}
 
 	processes := e.config.DefaultProcesses
+	if req.MaxProcesses > 0 {
+		processes = req.MaxProcesses
+	}
 	runArgs = append(runArgs, fmt.Sprintf("--processes=%d", processes))
 
 	runArgs = append(runArgs, "--run", "--")
@@ -187,6 +336,8 @@ func (e *IsolateExecutor) Execute(ctx context.Context, req RunRequest) (*Execute
 	// 5. Execute isolate run command
 	log.Printf("[%s] BoxID %s: Running command in sandbox: %s %v", e.ID(), boxIDStr, e.config.IsolatePath, runArgs)
 	cmdRun := exec.CommandContext(ctx, e.config.IsolatePath, runArgs...)
+	cmdRun.Stdin = req.Stdin
+	cmdRun.Stdout = req.Stdout
 	runErr := cmdRun.Run() // This error is often non-nil for non-zero exit, TLE, etc.
 	// We primarily rely on the meta file for status.
 
@@ -356,37 +507,3 @@ func parseIsolateMetaFile(filePath string) (*isolateMeta, error) {
 	}
 	return meta, nil
 }
-
-// You would then update the sandbox.NewExecutor factory function:
-// In internal/core/sandbox/interface.go
-/*
-func NewExecutor(runnerCfg config.RunnerConfig, allAppCfgFull *appconfig.Config) Executor { // Example: pass full config if isolate config is nested
-	switch runnerCfg.SandboxType {
-	case string(DirectSandbox):
-		return &directExecutor{
-			cfg: runnerCfg,
-		}
-	case string(IsolateSandbox):
-		// Assuming IsolateExecutorConfig is part of your main appConfig structure
-		// For example, if allAppCfgFull.Isolate is your IsolateExecutorConfig
-		isolateSpecificCfg := IsolateExecutorConfig{ // Populate this from your actual app config
-				IsolatePath:      allAppCfgFull.GetString("runner.isolate.path"), // Fictional config structure
-				EnvPath:          allAppCfgFull.GetString("runner.isolate.envPath"),
-				DefaultFsizeKb:   allAppCfgFull.GetInt("runner.isolate.fsizeKb"),
-				// ... and so on
-				TempDir:          allAppCfgFull.GetString("runner.isolate.tempDir"),
-		}
-		// Or, more directly if RunnerConfig contains IsolateExecutorConfig:
-		// isolateSpecificCfg := runnerCfg.IsolateConfig // If you add such a field
-		return NewIsolateExecutor(runnerCfg, isolateSpecificCfg)
-
-	// case string(FirejailSandbox):
-	//	 return nil // To be implemented
-	default:
-		log.Printf("Unsupported sandbox type: %s, falling back to direct executor or nil", runnerCfg.SandboxType)
-		// Fallback or error:
-		// return &directExecutor{cfg: runnerCfg} // Or handle error appropriately
-		return nil
-	}
-}
-*/
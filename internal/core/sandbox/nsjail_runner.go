@@ -0,0 +1,381 @@
+package sandbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+	nsjailpb "github.com/Mirai3103/remote-compiler/pkg/nsjail"
+)
+
+const (
+	// DefaultNsjailPath is the default path to the nsjail executable.
+	DefaultNsjailPath = "nsjail"
+	// DefaultNsjailExtraTimeSeconds is added on top of the wall-time limit given to nsjail.
+	DefaultNsjailExtraTimeSeconds = 1.0
+	// nsjailTmpfsSize is the size of the private /tmp mounted inside every jail.
+	nsjailTmpfsSize = "size=67108864" // 64 MB
+)
+
+// nsjailExecutor implements the sandbox.Executor interface using nsjail.
+// Each run gets its own NsJailConfig, derived from nsjailpb.DefaultConfig(),
+// which is serialized as textproto and passed to nsjail with --config.
+type nsjailExecutor struct {
+	cfg   config.RunnerConfig
+	nsCfg config.NsjailConfig
+}
+
+// newNsjailExecutor creates a new nsjailExecutor, filling unset values with defaults.
+func newNsjailExecutor(rc config.RunnerConfig) *nsjailExecutor {
+	nsCfg := rc.Nsjail
+	if nsCfg.NsjailPath == "" {
+		nsCfg.NsjailPath = DefaultNsjailPath
+	}
+	if nsCfg.EnvPath == "" {
+		nsCfg.EnvPath = DefaultEnvPath
+	}
+	if nsCfg.MaxProcesses == 0 {
+		nsCfg.MaxProcesses = DefaultProcesses
+	}
+	if nsCfg.FsizeKb == 0 {
+		nsCfg.FsizeKb = DefaultFsizeKb
+	}
+	if nsCfg.ExtraTimeSeconds == 0 {
+		nsCfg.ExtraTimeSeconds = DefaultNsjailExtraTimeSeconds
+	}
+	if nsCfg.WallTimeFactor == 0 {
+		nsCfg.WallTimeFactor = DefaultWallTimeFactor
+	}
+	return &nsjailExecutor{
+		cfg:   rc,
+		nsCfg: nsCfg,
+	}
+}
+
+// Validate checks that the nsjail binary can be found.
+func (e *nsjailExecutor) Validate() error {
+	if _, err := exec.LookPath(e.nsCfg.NsjailPath); err != nil {
+		return fmt.Errorf("nsjail binary %q not found: %w", e.nsCfg.NsjailPath, err)
+	}
+	return nil
+}
+
+// ID returns the identifier for this executor.
+func (e *nsjailExecutor) ID() string {
+	return "nsjail_executor_v1"
+}
+
+// ResolveExecutable looks name up in EnvPath as the jail sees it: paths under the bind-mounted
+// system directories and ReadOnlyMounts come from the host, everything else from ChrootDir.
+func (e *nsjailExecutor) ResolveExecutable(name string) (string, error) {
+	binds := map[string]string{} // Dst trong jail -> Src trên host
+	for _, m := range nsjailpb.DefaultConfig().GetMount() {
+		if !m.GetIsBind() || m.GetDst() == "/tmp" {
+			continue
+		}
+		if _, err := os.Stat(m.GetSrc()); err == nil {
+			binds[m.GetDst()] = m.GetSrc()
+		}
+	}
+	for _, dir := range e.nsCfg.ReadOnlyMounts {
+		binds[dir] = dir
+	}
+	return lookPathIn(name, e.nsCfg.EnvPath, func(p string) string {
+		for dst, src := range binds {
+			if underAny(p, []string{dst}) {
+				return filepath.Join(src, strings.TrimPrefix(p, filepath.Clean(dst)))
+			}
+		}
+		if e.nsCfg.ChrootDir != "" {
+			return filepath.Join(e.nsCfg.ChrootDir, p)
+		}
+		return p
+	})
+}
+
+// Compile runs the compile command inside a fresh nsjail.
+func (e *nsjailExecutor) Compile(ctx context.Context, req CompileRequest) (*CompileResult, error) {
+	return compileWithExecutor(ctx, e, req)
+}
+
+// Execute runs the command specified in RunRequest inside a fresh nsjail.
+func (e *nsjailExecutor) Execute(ctx context.Context, req RunRequest) (*ExecuteResult, error) {
+	log.Printf("[%s] Starting execution for SubmissionID: %s, TestCaseID: %s, Command: %v, TimeLimit: %dms, MemoryLimit: %dkB",
+		e.ID(), req.SubmissionID, req.TestCaseID, req.RunCommand, req.TimeLimitMs, req.MemoryLimitKb)
+	if len(req.RunCommand) == 0 {
+		return nil, &Error{Type: ErrInternal, Message: "empty run command"}
+	}
+
+	// 1. Per-run host directory for the nsjail config, stats and log files.
+	tempFileHostDir := e.nsCfg.TempDir
+	if tempFileHostDir == "" {
+		tempFileHostDir = os.TempDir()
+	}
+	runDir, err := os.MkdirTemp(tempFileHostDir, fmt.Sprintf("nsjail_%s_*", req.SubmissionID))
+	if err != nil {
+		return nil, &Error{Type: ErrInternal, Message: "failed to create nsjail temp directory", Cause: err}
+	}
+	defer os.RemoveAll(runDir)
+
+	configPath := filepath.Join(runDir, "config.textproto")
+	statsPath := filepath.Join(runDir, "stats.txt")
+	logPath := filepath.Join(runDir, "nsjail.log")
+
+	// 2. Build and write the jail config.
+	jailCfg := e.buildConfig(req, statsPath, logPath)
+	out, err := prototext.MarshalOptions{Multiline: true}.Marshal(jailCfg)
+	if err != nil {
+		return nil, &Error{Type: ErrInternal, Message: "failed to marshal nsjail config", Cause: err}
+	}
+	if err := os.WriteFile(configPath, out, 0644); err != nil {
+		return nil, &Error{Type: ErrInternal, Message: "failed to write nsjail config", Cause: err}
+	}
+
+	// 3. Run nsjail. nsjail passes its own stdio through to the jailed process,
+	// its own logs go to logPath so stderr only contains the program's output.
+	cmd := exec.CommandContext(ctx, e.nsCfg.NsjailPath, "--config", configPath)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = req.stdin()
+	cmd.Stdout = req.stdout(&stdout)
+	cmd.Stderr = &stderr
+
+	startTime := time.Now()
+	runErr := cmd.Run()
+	wallTimeMs := int(time.Since(startTime).Milliseconds())
+
+	exitCode := 0
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) && ctx.Err() == nil {
+			log.Printf("[%s] Failed to run nsjail for TestCaseID %s: %v", e.ID(), req.TestCaseID, runErr)
+			return nil, &Error{Type: ErrCmdStart, Message: "failed to run nsjail", Cause: runErr}
+		}
+		if exitErr != nil {
+			if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+				exitCode = ws.ExitStatus()
+			} else {
+				exitCode = -1
+			}
+		}
+	}
+
+	// 4. Parse the stats file written by nsjail.
+	stats, statsErr := parseNsjailStatsFile(statsPath)
+	if statsErr != nil {
+		if ctx.Err() != nil {
+			log.Printf("[%s] Context done for TestCaseID %s before nsjail wrote stats: %v", e.ID(), req.TestCaseID, ctx.Err())
+			return &ExecuteResult{
+				Status:     models.TimeLimitExceeded,
+				Stdout:     stdout.String(),
+				Stderr:     stderr.String(),
+				ExitCode:   -1,
+				TimeUsedMs: wallTimeMs,
+			}, nil
+		}
+		jailLog, _ := os.ReadFile(logPath)
+		log.Printf("[%s] No usable stats for TestCaseID %s (nsjail exit code %d): %v. nsjail log: %s",
+			e.ID(), req.TestCaseID, exitCode, statsErr, string(jailLog))
+		return nil, &Error{Type: ErrInternal, Message: "failed to read nsjail stats file", Cause: statsErr, Details: string(jailLog)}
+	}
+	log.Printf("[%s] Parsed stats for TestCaseID %s: %+v", e.ID(), req.TestCaseID, stats)
+
+	// 5. Determine final status.
+	result := &ExecuteResult{
+		Stdout:       stdout.String(),
+		Stderr:       stderr.String(),
+		ExitCode:     stats.ExitCode,
+		TimeUsedMs:   stats.CPUTimeMs,
+		MemoryUsedKb: stats.MaxMemoryKb,
+	}
+	if result.TimeUsedMs == 0 {
+		result.TimeUsedMs = stats.WallTimeMs
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) || stats.TimeLimitExceeded ||
+		(req.TimeLimitMs > 0 && stats.CPUTimeMs > req.TimeLimitMs):
+		result.Status = models.TimeLimitExceeded
+	case stats.OOMKilled || (req.MemoryLimitKb > 0 && stats.MaxMemoryKb > req.MemoryLimitKb):
+		result.Status = models.MemoryLimitExceeded
+	case stats.Signal != 0 || stats.ExitCode != 0:
+		result.Status = models.RuntimeError
+		if stats.Signal != 0 && result.Stderr == "" {
+			result.Stderr = fmt.Sprintf("Killed by signal %d.", stats.Signal)
+		}
+	default:
+		result.Status = models.Success
+	}
+
+	log.Printf("[%s] Finished execution for TestCaseID %s. Status: %s, Time: %dms, Mem: %dkB",
+		e.ID(), req.TestCaseID, result.Status, result.TimeUsedMs, result.MemoryUsedKb)
+	return result, nil
+}
+
+// buildConfig derives the NsJailConfig for a single run from nsjailpb.DefaultConfig().
+func (e *nsjailExecutor) buildConfig(req RunRequest, statsPath, logPath string) *nsjailpb.NsJailConfig {
+	cfg := nsjailpb.DefaultConfig()
+	cfg.Name = proto.String("runner-" + req.SubmissionID)
+	cfg.Cwd = proto.String(req.WorkingDirectory)
+	cfg.LogFile = proto.String(logPath)
+	cfg.StatsFile = proto.String(statsPath)
+	cfg.Envar = append([]string{"PATH=" + e.nsCfg.EnvPath}, req.Env...)
+
+	// Time: wall-time limit for nsjail itself, CPU rlimit for the program.
+	timeLimitSec := float64(req.TimeLimitMs) / 1000.0
+	wallTimeLimitSec := timeLimitSec*e.nsCfg.WallTimeFactor + e.nsCfg.ExtraTimeSeconds
+	cfg.TimeLimit = proto.Uint32(uint32(math.Ceil(wallTimeLimitSec)))
+	cfg.RlimitCpu = proto.Uint64(uint64(math.Ceil(timeLimitSec)) + 1)
+	cfg.RlimitCpuType = nsjailpb.RLimit_VALUE.Enum()
+
+	// Memory is enforced by the cgroup; the address space rlimit would break runtimes
+	// such as Go and the JVM which reserve much more virtual memory than they use.
+	cfg.RlimitAsType = nsjailpb.RLimit_INF.Enum()
+	if req.MemoryLimitKb > 0 {
+		cfg.CgroupMemMax = proto.Uint64(uint64(req.MemoryLimitKb) * 1024)
+		cfg.CgroupMemSwapMax = proto.Int64(0)
+	}
+	maxProcesses := e.nsCfg.MaxProcesses
+	if req.MaxProcesses > 0 {
+		maxProcesses = req.MaxProcesses
+	}
+	cfg.CgroupPidsMax = proto.Uint64(uint64(maxProcesses))
+	fsizeKb := e.nsCfg.FsizeKb
+	if req.FileSizeLimitKb > 0 {
+		fsizeKb = req.FileSizeLimitKb
+	}
+	cfg.RlimitFsize = proto.Uint64(uint64(max(fsizeKb/1024, 1)))
+	cfg.RlimitFsizeType = nsjailpb.RLimit_VALUE.Enum()
+
+	// Mounts: optional chroot first, then the default system directories that exist
+	// on this host, a private /tmp, extra read-only mounts and finally the working
+	// directory at the same path so host paths in RunCommand keep working.
+	var mounts []*nsjailpb.MountPt
+	if e.nsCfg.ChrootDir != "" {
+		mounts = append(mounts, &nsjailpb.MountPt{
+			Src:    proto.String(e.nsCfg.ChrootDir),
+			Dst:    proto.String("/"),
+			IsBind: proto.Bool(true),
+			Rw:     proto.Bool(false),
+		})
+	}
+	for _, m := range cfg.GetMount() {
+		if m.GetDst() == "/tmp" {
+			continue // never share the host /tmp with untrusted code
+		}
+		if _, err := os.Stat(m.GetSrc()); err != nil {
+			continue
+		}
+		mounts = append(mounts, m)
+	}
+	mounts = append(mounts, &nsjailpb.MountPt{
+		Dst:     proto.String("/tmp"),
+		Fstype:  proto.String("tmpfs"),
+		Options: proto.String(nsjailTmpfsSize),
+		Rw:      proto.Bool(true),
+	})
+	for _, dir := range e.nsCfg.ReadOnlyMounts {
+		mounts = append(mounts, &nsjailpb.MountPt{
+			Src:    proto.String(dir),
+			Dst:    proto.String(dir),
+			IsBind: proto.Bool(true),
+			Rw:     proto.Bool(false),
+		})
+	}
+	mounts = append(mounts, &nsjailpb.MountPt{
+		Src:    proto.String(req.WorkingDirectory),
+		Dst:    proto.String(req.WorkingDirectory),
+		IsBind: proto.Bool(true),
+		Rw:     proto.Bool(true),
+	})
+	cfg.Mount = mounts
+
+	// nsjail execve()s the binary without a PATH lookup, so bare command names
+	// like "python3" are resolved through /usr/bin/env inside the jail.
+	if filepath.IsAbs(req.RunCommand[0]) {
+		cfg.ExecBin = &nsjailpb.Exe{
+			Path: proto.String(req.RunCommand[0]),
+			Arg:  req.RunCommand[1:],
+		}
+	} else {
+		cfg.ExecBin = &nsjailpb.Exe{
+			Path: proto.String("/usr/bin/env"),
+			Arg:  req.RunCommand,
+		}
+	}
+	return cfg
+}
+
+// nsjailStats holds the data nsjail (export-stats branch) writes to its stats file.
+type nsjailStats struct {
+	ExitCode          int
+	Signal            int
+	CPUTimeMs         int
+	WallTimeMs        int
+	MaxMemoryKb       int
+	OOMKilled         bool
+	TimeLimitExceeded bool
+}
+
+// parseNsjailStatsFile parses the stats file, one "key: value" (or "key=value") pair per line.
+func parseNsjailStatsFile(filePath string) (*nsjailStats, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stats file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	stats := &nsjailStats{}
+	found := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			key, value, ok = strings.Cut(line, "=")
+		}
+		if !ok {
+			continue // Skip malformed lines
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		found = true
+		switch key {
+		case "exit_code":
+			stats.ExitCode, _ = strconv.Atoi(value)
+		case "signal":
+			stats.Signal, _ = strconv.Atoi(value)
+		case "cpu_time_ms":
+			stats.CPUTimeMs, _ = strconv.Atoi(value)
+		case "wall_time_ms":
+			stats.WallTimeMs, _ = strconv.Atoi(value)
+		case "max_memory_kb":
+			stats.MaxMemoryKb, _ = strconv.Atoi(value)
+		case "oom_killed":
+			stats.OOMKilled, _ = strconv.ParseBool(value)
+		case "time_limit_exceeded":
+			stats.TimeLimitExceeded, _ = strconv.ParseBool(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading stats file %s: %w", filePath, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("stats file %s is empty", filePath)
+	}
+	return stats, nil
+}
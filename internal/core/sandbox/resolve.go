@@ -0,0 +1,78 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinks bounds symlink resolution, like the kernel's ELOOP limit.
+const maxSymlinks = 40
+
+// hostPathFunc maps an absolute path as seen inside a sandbox to the host path of the same file.
+type hostPathFunc func(sandboxPath string) string
+
+// sameHostPath is the hostPathFunc of sandboxes that see the host filesystem at the same paths.
+func sameHostPath(p string) string { return p }
+
+// lookPathIn finds the executable the sandbox runs for name, searching envPath the way execvp
+// does inside the sandbox, and returns its host path with symlinks resolved inside the sandbox.
+// A name containing a slash is not searched; a relative one is returned unchanged, since it
+// refers to the (per-submission) working directory.
+func lookPathIn(name, envPath string, hostPath hostPathFunc) (string, error) {
+	if strings.Contains(name, "/") {
+		if !filepath.IsAbs(name) {
+			return name, nil
+		}
+		return resolveExecutable(filepath.Clean(name), hostPath)
+	}
+	for _, dir := range filepath.SplitList(envPath) {
+		if dir == "" || !filepath.IsAbs(dir) {
+			continue
+		}
+		if path, err := resolveExecutable(filepath.Join(dir, name), hostPath); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("executable %q not found in PATH %q", name, envPath)
+}
+
+// resolveExecutable follows symlinks of p inside the sandbox and returns the host path of the
+// regular, executable file it ends at.
+func resolveExecutable(p string, hostPath hostPathFunc) (string, error) {
+	for range maxSymlinks {
+		host := hostPath(p)
+		info, err := os.Lstat(host)
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+				return "", fmt.Errorf("%s is not an executable file", p)
+			}
+			return host, nil
+		}
+		target, err := os.Readlink(host)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(p), target)
+		}
+		p = filepath.Clean(target)
+	}
+	return "", errors.New("too many levels of symbolic links")
+}
+
+// underAny reports whether p is one of dirs or inside one of them.
+func underAny(p string, dirs []string) bool {
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		if p == dir || strings.HasPrefix(p, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// scoreboard theo dõi kết quả test case của submission có subtask, quyết định test case nào
+// được bỏ qua và tính điểm khi submission kết thúc.
+type scoreboard struct {
+	subtasks []models.Subtask
+	points   map[string]float64 // test case ID -> Points
+	memberOf map[string][]int   // test case ID -> index các subtask chứa test case
+	statuses map[string]models.TestcaseStatus
+}
+
+// newScoreboard kiểm tra các subtask của submission. Trả về nil nếu submission không có subtask.
+func newScoreboard(submission models.Submission) (*scoreboard, error) {
+	if len(submission.Subtasks) == 0 {
+		return nil, nil
+	}
+	b := &scoreboard{
+		subtasks: submission.Subtasks,
+		points:   make(map[string]float64, len(submission.TestCases)),
+		memberOf: make(map[string][]int),
+		statuses: make(map[string]models.TestcaseStatus, len(submission.TestCases)),
+	}
+	for _, tc := range submission.TestCases {
+		if tc.Points < 0 {
+			return nil, fmt.Errorf("test case %q: points must not be negative", tc.ID)
+		}
+		b.points[tc.ID] = tc.Points
+	}
+	seen := make(map[string]bool, len(submission.Subtasks))
+	for i, st := range submission.Subtasks {
+		if st.ID == "" {
+			return nil, fmt.Errorf("subtasks[%d]: id is required", i)
+		}
+		if seen[st.ID] {
+			return nil, fmt.Errorf("subtask %q: duplicate id", st.ID)
+		}
+		seen[st.ID] = true
+		switch st.Scoring {
+		case "", models.ScoringAllOrNothing, models.ScoringMin, models.ScoringSum:
+		default:
+			return nil, fmt.Errorf("subtask %q: unknown scoring %q", st.ID, st.Scoring)
+		}
+		if st.Score < 0 {
+			return nil, fmt.Errorf("subtask %q: score must not be negative", st.ID)
+		}
+		if len(st.TestCaseIDs) == 0 {
+			return nil, fmt.Errorf("subtask %q: no test cases", st.ID)
+		}
+		for _, id := range st.TestCaseIDs {
+			if _, ok := b.points[id]; !ok {
+				return nil, fmt.Errorf("subtask %q: unknown test case %q", st.ID, id)
+			}
+			b.memberOf[id] = append(b.memberOf[id], i)
+		}
+	}
+	return b, nil
+}
+
+// record lưu status của một test case.
+func (b *scoreboard) record(testCaseID string, status models.TestcaseStatus) {
+	b.statuses[testCaseID] = status
+}
+
+// shouldSkip cho biết test case có thể bỏ qua không: mọi subtask chứa nó đều đã có test case sai
+// và tính điểm theo "all_or_nothing" hoặc "min". Test case không thuộc subtask nào luôn được chạy.
+func (b *scoreboard) shouldSkip(testCaseID string) bool {
+	indexes := b.memberOf[testCaseID]
+	if len(indexes) == 0 {
+		return false
+	}
+	for _, i := range indexes {
+		st := b.subtasks[i]
+		if st.Scoring == models.ScoringSum || !b.failed(st) {
+			return false
+		}
+	}
+	return true
+}
+
+// failed cho biết subtask đã có test case sai (hoặc bị bỏ qua) hay chưa.
+func (b *scoreboard) failed(st models.Subtask) bool {
+	for _, id := range st.TestCaseIDs {
+		if status, ok := b.statuses[id]; ok && status != models.Success {
+			return true
+		}
+	}
+	return false
+}
+
+// result tính điểm từng subtask và tổng điểm. Test case chưa có kết quả được tính là sai.
+func (b *scoreboard) result(submissionID string) models.SubmissionScore {
+	score := models.SubmissionScore{SubmissionID: submissionID, Subtasks: make([]models.SubtaskScore, 0, len(b.subtasks))}
+	for _, st := range b.subtasks {
+		s := b.scoreSubtask(st)
+		score.Score += s.Score
+		score.MaxScore += s.MaxScore
+		score.Subtasks = append(score.Subtasks, s)
+	}
+	return score
+}
+
+func (b *scoreboard) scoreSubtask(st models.Subtask) models.SubtaskScore {
+	s := models.SubtaskScore{SubtaskID: st.ID, Status: models.Success, Total: len(st.TestCaseIDs)}
+	// Với "sum", test case không có Points được chia đều Score khi cả subtask không khai báo Points.
+	hasPoints := false
+	for _, id := range st.TestCaseIDs {
+		hasPoints = hasPoints || b.points[id] > 0
+	}
+	minEarned, minPoints := math.Inf(1), math.Inf(1)
+	for _, id := range st.TestCaseIDs {
+		status, ok := b.statuses[id]
+		if !ok {
+			status = models.Skipped
+		}
+		passed := status == models.Success
+		if passed {
+			s.Passed++
+		} else if s.Status == models.Success || s.Status == models.Skipped {
+			s.Status = status // Ưu tiên status của test case thực sự sai hơn Skipped
+		}
+
+		testPoints := b.points[id]
+		switch {
+		case st.Scoring == models.ScoringSum && !hasPoints:
+			testPoints = st.Score / float64(len(st.TestCaseIDs))
+		case st.Scoring == models.ScoringMin && testPoints == 0:
+			testPoints = st.Score
+		}
+		earned := 0.0
+		if passed {
+			earned = testPoints
+		}
+		switch st.Scoring {
+		case models.ScoringSum:
+			s.Score += earned
+			s.MaxScore += testPoints
+		case models.ScoringMin:
+			minEarned = math.Min(minEarned, earned)
+			minPoints = math.Min(minPoints, testPoints)
+		}
+	}
+
+	switch st.Scoring {
+	case models.ScoringSum:
+		// Đã cộng dồn trong vòng lặp
+	case models.ScoringMin:
+		s.Score, s.MaxScore = minEarned, minPoints
+	default: // all_or_nothing
+		s.MaxScore = st.Score
+		if s.Passed == s.Total {
+			s.Score = st.Score
+		}
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"time"
+
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// Reporter nhận kết quả của một submission. *nats.Publisher publish chúng lên NATS (submission chấm
+// bất đồng bộ); resultCollector gom chúng lại để trả lời request chạy code đồng bộ.
+type Reporter interface {
+	PublishSubmissionResult(result models.SubmissionResult) error
+	PublishSubmissionScore(score models.SubmissionScore) error
+	PublishSubmissionSummary(summary models.SubmissionSummary) error
+	PublishSubmissionProgress(progress models.SubmissionProgress)
+}
+
+// resultTracker publish kết quả từng test case và ghi nhận chúng để tính điểm (nếu có subtask)
+// và message tổng kết của submission, cùng các sự kiện tiến độ.
+type resultTracker struct {
+	reporter  Reporter
+	board     *scoreboard // nil nếu submission không có subtask
+	startedAt time.Time
+	summary   models.SubmissionSummary
+}
+
+func newResultTracker(submission models.Submission, reporter Reporter) *resultTracker {
+	return &resultTracker{
+		reporter:  reporter,
+		startedAt: time.Now(),
+		summary: models.SubmissionSummary{
+			SubmissionID: submission.ID,
+			Verdict:      models.Success,
+			Total:        len(submission.TestCases),
+		},
+	}
+}
+
+// publish gửi kết quả của một test case và cập nhật điểm và summary.
+func (t *resultTracker) publish(result models.SubmissionResult) {
+	if t.board != nil {
+		t.board.record(result.TestCaseID, result.Status)
+	}
+	if result.Status == models.Success {
+		t.summary.Passed++
+	} else if result.Status != models.Skipped {
+		t.fail(result.Status, "")
+	}
+	t.summary.MaxTimeInMs = max(t.summary.MaxTimeInMs, result.TimeUsedInMs)
+	t.summary.MaxMemoryInKb = max(t.summary.MaxMemoryInKb, result.MemoryUsedInKb)
+	t.reporter.PublishSubmissionResult(result)
+}
+
+// fail đặt verdict của submission nếu chưa có lỗi nào trước đó (verdict là lỗi đầu tiên).
+func (t *resultTracker) fail(status models.TestcaseStatus, errMsg string) {
+	if t.summary.Verdict == models.Success {
+		t.summary.Verdict = status
+		if errMsg != "" {
+			t.summary.Error = errMsg
+		}
+	}
+}
+
+// cancel đặt verdict của submission là Cancelled, thay cho lỗi trước đó nếu có.
+func (t *resultTracker) cancel(reason string) {
+	t.summary.Verdict = models.Cancelled
+	t.summary.Error = reason
+}
+
+// finish publish điểm (nếu có subtask) và message tổng kết. Gọi đúng một lần khi submission kết thúc.
+func (t *resultTracker) finish() {
+	if t.board != nil {
+		t.reporter.PublishSubmissionScore(t.board.result(t.summary.SubmissionID))
+	}
+	t.summary.DurationInMs = time.Since(t.startedAt).Milliseconds()
+	t.reporter.PublishSubmissionSummary(t.summary)
+	t.progress(models.SubmissionProgress{Stage: models.StageFinished, Status: t.summary.Verdict})
+}
+
+// progress publish một sự kiện tiến độ của submission.
+func (t *resultTracker) progress(event models.SubmissionProgress) {
+	event.SubmissionID = t.summary.SubmissionID
+	event.TotalTestCases = t.summary.Total
+	t.reporter.PublishSubmissionProgress(event)
+}
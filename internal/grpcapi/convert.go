@@ -0,0 +1,115 @@
+package grpcapi
+
+import (
+	"github.com/Mirai3103/remote-compiler/internal/models"
+	"github.com/Mirai3103/remote-compiler/pkg/judge"
+)
+
+// Chuyển đổi giữa message của pkg/judge và internal/models. Các trường có cùng ý nghĩa và cùng tên
+// (snake_case trong proto); status/verdict/stage là chuỗi giống hệt JSON.
+
+func toSubmission(s *judge.Submission) models.Submission {
+	submission := models.Submission{
+		ID:              s.GetId(),
+		LanguageID:      s.GetLanguageId(),
+		Code:            s.GetCode(),
+		TimeLimitInMs:   int(s.GetTimeLimitInMs()),
+		MemoryLimitInKb: int(s.GetMemoryLimitInKb()),
+		CompileFlags:    s.GetCompileFlags(),
+		Checker:         toChecker(s.GetChecker()),
+		Interactor:      toChecker(s.GetInteractor()),
+	}
+	for _, tc := range s.GetTestCases() {
+		submission.TestCases = append(submission.TestCases, models.TestCase{
+			ID:           tc.GetId(),
+			Input:        tc.GetInput(),
+			ExpectOutput: tc.GetExpectOutput(),
+			Points:       tc.GetPoints(),
+		})
+	}
+	if settings := s.GetSettings(); settings != nil {
+		submission.Settings = models.SubmissionSettings{
+			WithTrim:           settings.GetWithTrim(),
+			WithCaseSensitive:  settings.GetWithCaseSensitive(),
+			WithWhitespace:     settings.GetWithWhitespace(),
+			ComparisonMode:     settings.GetComparisonMode(),
+			AbsoluteEpsilon:    settings.GetAbsoluteEpsilon(),
+			RelativeEpsilon:    settings.GetRelativeEpsilon(),
+			StopOnFirstFailure: settings.GetStopOnFirstFailure(),
+		}
+	}
+	for _, st := range s.GetSubtasks() {
+		submission.Subtasks = append(submission.Subtasks, models.Subtask{
+			ID:          st.GetId(),
+			Score:       st.GetScore(),
+			Scoring:     st.GetScoring(),
+			TestCaseIDs: st.GetTestCaseIds(),
+		})
+	}
+	return submission
+}
+
+func toChecker(c *judge.Checker) *models.Checker {
+	if c == nil {
+		return nil
+	}
+	return &models.Checker{ID: c.GetId(), LanguageID: c.GetLanguageId(), Code: c.GetCode()}
+}
+
+func fromResult(r models.SubmissionResult) *judge.TestResult {
+	return &judge.TestResult{
+		SubmissionId:   r.SubmissionID,
+		TestCaseId:     r.TestCaseID,
+		Status:         string(r.Status),
+		TimeUsedInMs:   int32(r.TimeUsedInMs),
+		MemoryUsedInKb: int32(r.MemoryUsedInKb),
+		Output:         r.Output,
+		Error:          r.Error,
+		CompileCache:   r.CompileCache,
+		CheckerMessage: r.CheckerMessage,
+		Transcript:     r.Transcript,
+	}
+}
+
+func fromScore(s models.SubmissionScore) *judge.Score {
+	score := &judge.Score{SubmissionId: s.SubmissionID, Score: s.Score, MaxScore: s.MaxScore}
+	for _, st := range s.Subtasks {
+		score.Subtasks = append(score.Subtasks, &judge.SubtaskScore{
+			SubtaskId: st.SubtaskID,
+			Score:     st.Score,
+			MaxScore:  st.MaxScore,
+			Status:    string(st.Status),
+			Passed:    int32(st.Passed),
+			Total:     int32(st.Total),
+		})
+	}
+	return score
+}
+
+func fromSummary(s models.SubmissionSummary) *judge.Summary {
+	return &judge.Summary{
+		SubmissionId:  s.SubmissionID,
+		Verdict:       string(s.Verdict),
+		Passed:        int32(s.Passed),
+		Total:         int32(s.Total),
+		MaxTimeInMs:   int32(s.MaxTimeInMs),
+		MaxMemoryInKb: int32(s.MaxMemoryInKb),
+		CompileLog:    s.CompileLog,
+		CompileCache:  s.CompileCache,
+		DurationInMs:  s.DurationInMs,
+		Error:         s.Error,
+	}
+}
+
+func fromProgress(p models.SubmissionProgress) *judge.Progress {
+	return &judge.Progress{
+		SubmissionId:   p.SubmissionID,
+		Stage:          string(p.Stage),
+		Status:         string(p.Status),
+		TestCaseId:     p.TestCaseID,
+		TestCaseIndex:  int32(p.TestCaseIndex),
+		TotalTestCases: int32(p.TotalTestCases),
+		QueueWaitInMs:  p.QueueWaitInMs,
+		Timestamp:      p.Timestamp,
+	}
+}
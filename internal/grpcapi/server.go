@@ -0,0 +1,198 @@
+// Package grpcapi phục vụ service Judge (assets/judge.proto, code sinh ra ở pkg/judge) song song với NATS.
+// Submission được chấm qua cùng worker.JobHandler với submission nhận từ NATS, nên dùng chung giới hạn
+// maxConcurrentJobs và có thể bị hủy qua submission.cancel.
+package grpcapi
+
+import (
+	"context"
+	"log"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+	natsClient "github.com/Mirai3103/remote-compiler/internal/nats"
+	"github.com/Mirai3103/remote-compiler/internal/worker"
+	"github.com/Mirai3103/remote-compiler/pkg/judge"
+)
+
+// Server là gRPC server của service Judge.
+type Server struct {
+	judge.UnimplementedJudgeServer
+
+	cfg       config.GRPCConfig
+	handler   *worker.JobHandler
+	publisher *natsClient.Publisher // Sự kiện tiến độ và yêu cầu hủy vẫn đi qua NATS
+	languages []config.LanguageConfig
+	srv       *grpc.Server
+}
+
+// NewServer tạo Server; gọi Start để bắt đầu lắng nghe.
+func NewServer(cfg config.GRPCConfig, handler *worker.JobHandler, publisher *natsClient.Publisher, languages []config.LanguageConfig) *Server {
+	s := &Server{
+		cfg:       cfg,
+		handler:   handler,
+		publisher: publisher,
+		languages: languages,
+		srv:       grpc.NewServer(),
+	}
+	judge.RegisterJudgeServer(s.srv, s)
+	return s
+}
+
+// Start mở cổng và phục vụ RPC trong goroutine riêng. Lỗi mở cổng được trả về ngay.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.srv.Serve(listener); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+	log.Printf("gRPC API listening on %s", listener.Addr())
+	return nil
+}
+
+// Shutdown chờ các RPC đang chạy kết thúc; hết ctx thì đóng mọi kết nối (submission của chúng bị dừng).
+func (s *Server) Shutdown(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.srv.Stop()
+	}
+}
+
+// Submit chấm submission, stream kết quả qua streamReporter. Client ngắt kết nối thì submission dừng.
+func (s *Server) Submit(req *judge.SubmitRequest, stream judge.Judge_SubmitServer) error {
+	if req.GetSubmission() == nil {
+		return status.Error(codes.InvalidArgument, "submission is required")
+	}
+	submission := toSubmission(req.GetSubmission())
+	if submission.ID == "" {
+		id, err := models.NewSubmissionID()
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		submission.ID = id
+	}
+	log.Printf("gRPC API: Submit SubmissionID %s", submission.ID)
+	reporter := &streamReporter{stream: stream, progress: s.publisher}
+	s.handler.HandleSubmissionWith(stream.Context(), submission, reporter)
+	return reporter.err
+}
+
+// Run chấm submission và trả về mọi kết quả trong một response, như submission.run.
+func (s *Server) Run(ctx context.Context, req *judge.RunRequest) (*judge.RunResponse, error) {
+	if req.GetSubmission() == nil {
+		return nil, status.Error(codes.InvalidArgument, "submission is required")
+	}
+	submission := toSubmission(req.GetSubmission())
+	if submission.ID == "" {
+		id, err := models.NewSubmissionID()
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		submission.ID = id
+	}
+	log.Printf("gRPC API: Run SubmissionID %s", submission.ID)
+	result := s.handler.HandleRunRequest(ctx, models.RunSubmissionRequest{
+		Submission:  submission,
+		TimeoutInMs: int(req.GetTimeoutInMs()),
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	if result.Busy {
+		return nil, status.Error(codes.ResourceExhausted, result.Error)
+	}
+
+	resp := &judge.RunResponse{Summary: fromSummary(result.Summary)}
+	for _, r := range result.Results {
+		resp.Results = append(resp.Results, fromResult(r))
+	}
+	if result.Score != nil {
+		resp.Score = fromScore(*result.Score)
+	}
+	return resp, nil
+}
+
+// Cancel publish yêu cầu hủy lên NATS để runner đang giữ submission (có thể là runner khác) dừng nó.
+func (s *Server) Cancel(ctx context.Context, req *judge.CancelRequest) (*judge.CancelResponse, error) {
+	if req.GetSubmissionId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "submission_id is required")
+	}
+	err := s.publisher.PublishSubmissionCancel(models.SubmissionCancel{
+		SubmissionID: req.GetSubmissionId(),
+		Reason:       req.GetReason(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to publish cancel request: %v", err)
+	}
+	return &judge.CancelResponse{}, nil
+}
+
+// ListLanguages trả về các ngôn ngữ trong config "languages" của runner.
+func (s *Server) ListLanguages(ctx context.Context, req *judge.ListLanguagesRequest) (*judge.ListLanguagesResponse, error) {
+	resp := &judge.ListLanguagesResponse{}
+	for _, lang := range s.languages {
+		resp.Languages = append(resp.Languages, &judge.Language{
+			Id:           lang.ID,
+			Name:         lang.Name,
+			Compiled:     lang.CompileCommand != "",
+			AllowedFlags: lang.AllowedFlags,
+		})
+	}
+	return resp, nil
+}
+
+// streamReporter là core.Reporter gửi kết quả của submission qua stream của Submit.
+// resultTracker gọi nó tuần tự nên không cần khóa. Sau lỗi gửi đầu tiên (thường là client đã ngắt kết nối),
+// các sự kiện còn lại bị bỏ qua.
+type streamReporter struct {
+	stream   judge.Judge_SubmitServer
+	progress *natsClient.Publisher
+	err      error
+}
+
+func (r *streamReporter) send(event *judge.SubmitEvent) error {
+	if r.err == nil {
+		if r.err = r.stream.Send(event); r.err != nil {
+			log.Printf("gRPC API: error sending event: %v", r.err)
+		}
+	}
+	return r.err
+}
+
+func (r *streamReporter) PublishSubmissionResult(result models.SubmissionResult) error {
+	return r.send(&judge.SubmitEvent{Event: &judge.SubmitEvent_Result{Result: fromResult(result)}})
+}
+
+func (r *streamReporter) PublishSubmissionScore(score models.SubmissionScore) error {
+	return r.send(&judge.SubmitEvent{Event: &judge.SubmitEvent_Score{Score: fromScore(score)}})
+}
+
+func (r *streamReporter) PublishSubmissionSummary(summary models.SubmissionSummary) error {
+	return r.send(&judge.SubmitEvent{Event: &judge.SubmitEvent_Summary{Summary: fromSummary(summary)}})
+}
+
+func (r *streamReporter) PublishSubmissionProgress(progress models.SubmissionProgress) {
+	if progress.Timestamp == 0 {
+		progress.Timestamp = time.Now().UnixMilli()
+	}
+	r.progress.PublishSubmissionProgress(progress)
+	if progress.Stage == models.StageFinished { // Summary đã là sự kiện cuối cùng của stream
+		return
+	}
+	r.send(&judge.SubmitEvent{Event: &judge.SubmitEvent_Progress{Progress: fromProgress(progress)}})
+}
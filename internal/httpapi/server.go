@@ -0,0 +1,260 @@
+// Package httpapi là HTTP/JSON API của runner cho client không dùng NATS. Submission được chấm qua
+// cùng worker.JobHandler với submission nhận từ NATS, nên dùng chung giới hạn maxConcurrentJobs.
+//
+//	POST /submissions               chấm submission (đồng bộ hoặc bất đồng bộ, xem Server.handleCreate)
+//	GET  /submissions/{id}          kết quả của submission bất đồng bộ
+//	GET  /submissions/{id}/events   kết quả từng test case qua Server-Sent Events
+//	GET  /stats                     trạng thái hàng đợi và worker của runner
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/core"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+	natsClient "github.com/Mirai3103/remote-compiler/internal/nats"
+	"github.com/Mirai3103/remote-compiler/internal/worker"
+)
+
+const (
+	sseKeepAlive      = 15 * time.Second // Comment định kỳ để proxy không đóng kết nối SSE đang rảnh
+	readHeaderTimeout = 10 * time.Second
+	busyRetryAfter    = "5" // Retry-After (giây) khi đã đủ submission bất đồng bộ đang chờ
+)
+
+// Server là HTTP server của API.
+type Server struct {
+	cfg      config.HTTPConfig
+	handler  *worker.JobHandler
+	progress core.Reporter // Nhận sự kiện tiến độ của submission bất đồng bộ (NATS publisher)
+	intake   *natsClient.Intake
+	store    *store
+	srv      *http.Server
+	closing  chan struct{}  // Bị đóng khi Shutdown, để kết thúc các kết nối SSE
+	jobs     sync.WaitGroup // Submission bất đồng bộ đang chạy
+	pending  chan struct{}  // Mỗi submission bất đồng bộ chưa xong giữ một phần tử (tối đa http.maxPendingAsync)
+}
+
+// NewServer tạo Server; gọi Start để bắt đầu lắng nghe. intake (nhận submission từ NATS) dùng cho GET /stats.
+func NewServer(cfg config.HTTPConfig, handler *worker.JobHandler, progress core.Reporter, intake *natsClient.Intake) *Server {
+	s := &Server{
+		cfg:      cfg,
+		handler:  handler,
+		progress: progress,
+		intake:   intake,
+		store:    newStore(time.Duration(cfg.ResultTTLSec) * time.Second),
+		closing:  make(chan struct{}),
+		pending:  make(chan struct{}, max(cfg.MaxPendingAsync, 1)),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /submissions", s.handleCreate)
+	mux.HandleFunc("GET /submissions/{id}", s.handleGet)
+	mux.HandleFunc("GET /submissions/{id}/events", s.handleEvents)
+	mux.HandleFunc("GET /stats", s.handleStats)
+	// Không đặt WriteTimeout: request đồng bộ có thể chạy tới thời hạn của nó và SSE giữ kết nối lâu.
+	s.srv = &http.Server{Addr: cfg.Addr, Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
+	return s
+}
+
+// Start mở cổng và phục vụ request trong goroutine riêng. Lỗi mở cổng được trả về ngay.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP API server stopped: %v", err)
+		}
+	}()
+	log.Printf("HTTP API listening on %s", listener.Addr())
+	return nil
+}
+
+// Shutdown ngừng nhận request, đóng các kết nối SSE rồi chờ request và submission bất đồng bộ đang chạy.
+func (s *Server) Shutdown(ctx context.Context) error {
+	close(s.closing)
+	err := s.srv.Shutdown(ctx)
+	s.jobs.Wait()
+	return err
+}
+
+// acceptedResponse là response của submission được chấm bất đồng bộ.
+type acceptedResponse struct {
+	SubmissionID string `json:"submissionId"`
+	StatusURL    string `json:"statusUrl"`
+	EventsURL    string `json:"eventsUrl"`
+}
+
+// handleCreate nhận models.Submission (có thể kèm timeoutInMs như models.RunSubmissionRequest).
+// Submission có tối đa http.syncMaxTestCases test case được chấm đồng bộ: response là
+// models.RunSubmissionResponse với mọi kết quả. Submission lớn hơn được chấm bất đồng bộ: response 202
+// chứa submission ID, kết quả lấy qua GET /submissions/{id} hoặc /events. ?mode=sync|async để chọn.
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req models.RunSubmissionRequest
+	body := http.MaxBytesReader(w, r.Body, int64(s.cfg.MaxBodyMb)*1024*1024)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid submission: %v", err))
+		return
+	}
+	if req.ID == "" {
+		id, err := models.NewSubmissionID()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		req.ID = id
+	}
+	if err := models.ValidateSubmissionID(req.ID); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var runSync bool
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "":
+		runSync = len(req.TestCases) <= s.cfg.SyncMaxTestCases
+	case "sync", "async":
+		runSync = mode == "sync"
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown mode %q (want sync or async)", mode))
+		return
+	}
+
+	if runSync {
+		log.Printf("HTTP API: running SubmissionID %s synchronously", req.ID)
+		resp := s.handler.HandleRunRequest(r.Context(), req)
+		if resp.Busy { // Không có slot trước khi hết timeoutInMs, submission không được chạy
+			w.Header().Set("Retry-After", busyRetryAfter)
+			writeJSON(w, http.StatusServiceUnavailable, resp)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	// Submission bất đồng bộ chờ slot trong goroutine, nên số lượng phải có giới hạn,
+	// giống intake của NATS: khi đã đủ thì client gửi lại sau thay vì dồn vào bộ nhớ.
+	select {
+	case s.pending <- struct{}{}:
+	default:
+		w.Header().Set("Retry-After", busyRetryAfter)
+		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("too many pending submissions (%d), retry later", cap(s.pending)))
+		return
+	}
+	e, ok := s.store.create(req.ID, s.progress)
+	if !ok {
+		<-s.pending
+		writeError(w, http.StatusConflict, fmt.Sprintf("submission %q is already running", req.ID))
+		return
+	}
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		defer func() { <-s.pending }()
+		s.handler.HandleSubmissionWith(context.Background(), req.Submission, e)
+	}()
+	log.Printf("HTTP API: accepted SubmissionID %s for asynchronous judging", req.ID)
+	statusURL := "/submissions/" + url.PathEscape(req.ID)
+	writeJSON(w, http.StatusAccepted, acceptedResponse{
+		SubmissionID: req.ID,
+		StatusURL:    statusURL,
+		EventsURL:    statusURL + "/events",
+	})
+}
+
+// handleGet trả về kết quả hiện có của một submission bất đồng bộ.
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	e := s.store.get(r.PathValue("id"))
+	if e == nil {
+		writeError(w, http.StatusNotFound, "submission not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, e.snapshot())
+}
+
+// handleEvents stream các sự kiện của submission bất đồng bộ qua Server-Sent Events: các sự kiện đã có
+// được gửi lại trước, sau đó là sự kiện mới cho tới "summary". Mỗi sự kiện có id là số thứ tự, client
+// kết nối lại với Last-Event-ID chỉ nhận các sự kiện sau đó.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	e := s.store.get(r.PathValue("id"))
+	if e == nil {
+		writeError(w, http.StatusNotFound, "submission not found")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	next := 0
+	if lastID, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && lastID >= 0 {
+		next = lastID + 1
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Tắt buffer của nginx
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		events, updated, done := e.since(next)
+		for _, ev := range events {
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", next, ev.name, ev.data); err != nil {
+				return
+			}
+			next++
+		}
+		flusher.Flush()
+		if done {
+			return
+		}
+		select {
+		case <-updated:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+// runnerStats là response của GET /stats.
+type runnerStats struct {
+	Intake natsClient.IntakeStats `json:"intake"` // Nhận submission từ NATS
+	Jobs   worker.JobStats        `json:"jobs"`   // Mọi submission, kể cả từ HTTP và gRPC
+}
+
+// handleStats trả về số worker bận/rảnh, số submission đang chờ slot và, với JetStream, độ sâu hàng đợi.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, runnerStats{Intake: s.intake.Stats(), Jobs: s.handler.Stats()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("HTTP API: error writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
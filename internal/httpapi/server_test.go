@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+)
+
+func TestCreateRejectsInvalidID(t *testing.T) {
+	s := NewServer(config.HTTPConfig{SyncMaxTestCases: 5, MaxBodyMb: 1, MaxPendingAsync: 1}, nil, nil, nil)
+	for _, mode := range []string{"sync", "async"} {
+		rec := httptest.NewRecorder()
+		s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/submissions?mode="+mode,
+			strings.NewReader(`{"id": "../etc", "languageId": "python"}`)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("mode %s: status = %d, want 400 (body %s)", mode, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestCreateRejectsWhenTooManyPending(t *testing.T) {
+	s := NewServer(config.HTTPConfig{SyncMaxTestCases: 5, MaxBodyMb: 1, MaxPendingAsync: 2}, nil, nil, nil)
+	// Giả lập 2 submission bất đồng bộ đang chờ slot.
+	s.pending <- struct{}{}
+	s.pending <- struct{}{}
+
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/submissions?mode=async",
+		strings.NewReader(`{"id": "s1", "languageId": "python"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 (body %s)", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("503 response has no Retry-After header")
+	}
+	if s.store.get("s1") != nil {
+		t.Error("rejected submission was added to the store")
+	}
+}
@@ -0,0 +1,178 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Mirai3103/remote-compiler/internal/core"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// Tên sự kiện Server-Sent Events của một submission.
+const (
+	eventProgress = "progress"
+	eventResult   = "result"
+	eventScore    = "score"
+	eventSummary  = "summary" // Luôn là sự kiện cuối cùng
+)
+
+// event là một sự kiện của submission, gửi cho client qua Server-Sent Events.
+type event struct {
+	name string
+	data []byte
+}
+
+// submissionStatus là response của GET /submissions/{id}.
+type submissionStatus struct {
+	SubmissionID string                    `json:"submissionId"`
+	Finished     bool                      `json:"finished"`
+	Stage        models.ProgressStage      `json:"stage"` // Giai đoạn gần nhất
+	Results      []models.SubmissionResult `json:"results"`
+	Score        *models.SubmissionScore   `json:"score,omitempty"`   // Chỉ có khi submission có subtask
+	Summary      *models.SubmissionSummary `json:"summary,omitempty"` // Có khi submission đã xong
+}
+
+// entry là trạng thái của một submission bất đồng bộ. Nó là core.Reporter của submission đó:
+// kết quả được giữ lại để trả cho GET và được phát lại cho mỗi client Server-Sent Events.
+type entry struct {
+	progress core.Reporter // Sự kiện tiến độ vẫn được publish lên NATS như submission thường
+
+	mu         sync.Mutex
+	status     submissionStatus
+	events     []event
+	updated    chan struct{} // Bị đóng (và thay bằng channel mới) mỗi khi có sự kiện mới
+	finishedAt time.Time
+}
+
+func newEntry(submissionID string, progress core.Reporter) *entry {
+	return &entry{
+		progress: progress,
+		status: submissionStatus{
+			SubmissionID: submissionID,
+			Stage:        models.StageReceived,
+			Results:      []models.SubmissionResult{},
+		},
+		updated: make(chan struct{}),
+	}
+}
+
+// addLocked ghi nhận một sự kiện và đánh thức các client đang chờ. Gọi khi đang giữ e.mu.
+func (e *entry) addLocked(name string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Error marshalling %s event of SubmissionID %s: %v", name, e.status.SubmissionID, err)
+		return
+	}
+	e.events = append(e.events, event{name: name, data: data})
+	close(e.updated)
+	e.updated = make(chan struct{})
+}
+
+func (e *entry) PublishSubmissionResult(result models.SubmissionResult) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.status.Results = append(e.status.Results, result)
+	e.addLocked(eventResult, result)
+	return nil
+}
+
+func (e *entry) PublishSubmissionScore(score models.SubmissionScore) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.status.Score = &score
+	e.addLocked(eventScore, score)
+	return nil
+}
+
+func (e *entry) PublishSubmissionSummary(summary models.SubmissionSummary) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.status.Summary = &summary
+	e.status.Finished = true
+	e.status.Stage = models.StageFinished
+	e.finishedAt = time.Now()
+	e.addLocked(eventSummary, summary)
+	return nil
+}
+
+func (e *entry) PublishSubmissionProgress(progress models.SubmissionProgress) {
+	if progress.Timestamp == 0 {
+		progress.Timestamp = time.Now().UnixMilli()
+	}
+	e.progress.PublishSubmissionProgress(progress)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.status.Finished { // Sự kiện "finished" tới sau summary; summary đã là sự kiện cuối cùng
+		return
+	}
+	e.status.Stage = progress.Stage
+	e.addLocked(eventProgress, progress)
+}
+
+// snapshot trả về bản sao trạng thái hiện tại.
+func (e *entry) snapshot() submissionStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	status := e.status
+	status.Results = append([]models.SubmissionResult{}, e.status.Results...)
+	return status
+}
+
+// since trả về các sự kiện từ vị trí next, channel bị đóng khi có sự kiện mới,
+// và done = true nếu submission đã xong (không còn sự kiện nào sau các sự kiện trả về).
+func (e *entry) since(next int) ([]event, <-chan struct{}, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if next > len(e.events) {
+		next = len(e.events)
+	}
+	return e.events[next:], e.updated, e.status.Finished
+}
+
+// store giữ trạng thái của các submission bất đồng bộ, theo submission ID.
+// Submission đã xong bị xóa sau ttl.
+type store struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func newStore(ttl time.Duration) *store {
+	return &store{ttl: ttl, entries: make(map[string]*entry)}
+}
+
+// create thêm submission mới. Trả về false nếu một submission cùng ID vẫn đang chạy.
+func (s *store) create(submissionID string, progress core.Reporter) (*entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(time.Now())
+	if existing, ok := s.entries[submissionID]; ok && !existing.snapshot().Finished {
+		return nil, false
+	}
+	e := newEntry(submissionID, progress)
+	s.entries[submissionID] = e
+	return e, true
+}
+
+// get trả về submission theo ID, nil nếu không có hoặc đã hết hạn.
+func (s *store) get(submissionID string) *entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(time.Now())
+	return s.entries[submissionID]
+}
+
+// pruneLocked xóa các submission đã xong quá ttl. Gọi khi đang giữ s.mu.
+func (s *store) pruneLocked(now time.Time) {
+	for id, e := range s.entries {
+		e.mu.Lock()
+		expired := e.status.Finished && now.Sub(e.finishedAt) > s.ttl
+		e.mu.Unlock()
+		if expired {
+			delete(s.entries, id)
+		}
+	}
+}
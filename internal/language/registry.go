@@ -0,0 +1,170 @@
+// Package language chứa registry các ngôn ngữ mà runner hỗ trợ.
+// Lệnh biên dịch và chạy chỉ được lấy từ config của runner, submission chỉ tham chiếu tới language ID.
+package language
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+)
+
+// defaultBinaryFile là tên file output khi ngôn ngữ có biên dịch nhưng không khai báo binaryFile.
+const defaultBinaryFile = "executable"
+
+// Language là cách biên dịch và chạy một ngôn ngữ, đã được kiểm tra và parse từ config.
+type Language struct {
+	ID             string
+	SourceFile     string   // Tên file source trong thư mục tạm, ví dụ "main.cpp"
+	BinaryFile     string   // Tên file output của trình biên dịch; rỗng với ngôn ngữ thông dịch
+	CompileCommand string   // Template gốc của lệnh biên dịch (dùng làm key của compile cache)
+	Flags          []string // Flag biên dịch của submission, đã được kiểm tra với allowedFlags
+
+	compile *Template // nil với ngôn ngữ thông dịch
+	run     *Template
+}
+
+// IsCompiled cho biết ngôn ngữ có bước biên dịch hay không.
+func (l Language) IsCompiled() bool {
+	return l.compile != nil
+}
+
+// CompileSteps trả về argv của từng bước biên dịch, với flag của submission ở vị trí {flags}.
+func (l Language) CompileSteps(vars Vars) [][]string {
+	if l.compile == nil {
+		return nil
+	}
+	vars.Flags = l.Flags
+	return l.compile.Render(vars)
+}
+
+// RunArgs trả về argv của lệnh chạy.
+func (l Language) RunArgs(vars Vars) []string {
+	vars.Flags = nil
+	return l.run.Render(vars)[0] // runCommand luôn có đúng một bước, đã kiểm tra khi load
+}
+
+// Registry giữ các ngôn ngữ đã được cấu hình, theo ID.
+type Registry struct {
+	languages map[string]Language
+	allowed   map[string][]string // language ID -> allowedFlags
+}
+
+// NewRegistry kiểm tra và đăng ký các ngôn ngữ trong config.
+func NewRegistry(languages []config.LanguageConfig) (*Registry, error) {
+	r := &Registry{
+		languages: make(map[string]Language, len(languages)),
+		allowed:   make(map[string][]string, len(languages)),
+	}
+	for i, cfg := range languages {
+		if cfg.ID == "" {
+			return nil, fmt.Errorf("languages[%d]: id is required", i)
+		}
+		if _, exists := r.languages[cfg.ID]; exists {
+			return nil, fmt.Errorf("language %q: duplicate id", cfg.ID)
+		}
+		lang, err := parse(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("language %q: %w", cfg.ID, err)
+		}
+		r.languages[cfg.ID] = lang
+		r.allowed[cfg.ID] = cfg.AllowedFlags
+	}
+	return r, nil
+}
+
+// parse kiểm tra các giá trị mà runner sẽ dùng để ghép đường dẫn và lệnh, và parse các template.
+func parse(cfg config.LanguageConfig) (Language, error) {
+	lang := Language{
+		ID:             cfg.ID,
+		SourceFile:     cfg.SourceFile,
+		CompileCommand: cfg.CompileCommand,
+	}
+	if err := validateFileName(cfg.SourceFile); err != nil {
+		return Language{}, fmt.Errorf("sourceFile: %w", err)
+	}
+
+	if cfg.RunCommand == "" {
+		return Language{}, fmt.Errorf("runCommand is required")
+	}
+	run, err := ParseTemplate(cfg.RunCommand)
+	if err != nil {
+		return Language{}, fmt.Errorf("runCommand: %w", err)
+	}
+	if run.Steps() != 1 {
+		return Language{}, fmt.Errorf("runCommand: must be a single command, multiple steps are only supported in compileCommand")
+	}
+	if strings.Contains(cfg.RunCommand, "{"+PlaceholderFlags+"}") {
+		return Language{}, fmt.Errorf("runCommand: {%s} is only supported in compileCommand", PlaceholderFlags)
+	}
+	lang.run = run
+
+	if cfg.CompileCommand != "" {
+		compile, err := ParseTemplate(cfg.CompileCommand)
+		if err != nil {
+			return Language{}, fmt.Errorf("compileCommand: %w", err)
+		}
+		lang.compile = compile
+		lang.BinaryFile = cfg.BinaryFile
+		if lang.BinaryFile == "" {
+			lang.BinaryFile = defaultBinaryFile
+		}
+		if err := validateFileName(lang.BinaryFile); err != nil {
+			return Language{}, fmt.Errorf("binaryFile: %w", err)
+		}
+	}
+
+	if len(cfg.AllowedFlags) > 0 && !strings.Contains(cfg.CompileCommand, "{"+PlaceholderFlags+"}") {
+		return Language{}, fmt.Errorf("allowedFlags is set but compileCommand has no {%s} placeholder", PlaceholderFlags)
+	}
+	for _, flag := range cfg.AllowedFlags {
+		if flag == "" || strings.ContainsAny(flag, " \t\n") {
+			return Language{}, fmt.Errorf("allowed flag %q must be a single non-empty argument", flag)
+		}
+	}
+	return lang, nil
+}
+
+// validateFileName chỉ chấp nhận tên file nằm ngay trong thư mục tạm của submission.
+func validateFileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return fmt.Errorf("%q must be a plain file name", name)
+	}
+	return nil
+}
+
+// IDs trả về danh sách language ID đã đăng ký, đã sắp xếp.
+func (r *Registry) IDs() []string {
+	ids := make([]string, 0, len(r.languages))
+	for id := range r.languages {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Len trả về số ngôn ngữ đã đăng ký.
+func (r *Registry) Len() int {
+	return len(r.languages)
+}
+
+// Resolve trả về ngôn ngữ có ID tương ứng, kèm flags của submission.
+// Trả về lỗi nếu ID không được đăng ký hoặc có flag không được phép.
+func (r *Registry) Resolve(id string, flags []string) (Language, error) {
+	lang, ok := r.languages[id]
+	if !ok {
+		return Language{}, fmt.Errorf("unsupported language %q", id)
+	}
+	for _, flag := range flags {
+		if !slices.Contains(r.allowed[id], flag) {
+			return Language{}, fmt.Errorf("compile flag %q is not allowed for language %q", flag, id)
+		}
+	}
+	lang.Flags = slices.Clone(flags)
+	return lang, nil
+}
@@ -0,0 +1,273 @@
+package language
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Các placeholder được hỗ trợ trong compileCommand/runCommand.
+const (
+	PlaceholderSourceFile     = "source_file"     // Đường dẫn tuyệt đối tới file source
+	PlaceholderSourceBasename = "source_basename" // Tên file source không có thư mục và đuôi, ví dụ "Main"
+	PlaceholderOutputFile     = "output_file"     // Đường dẫn tuyệt đối tới file output của trình biên dịch (binaryFile)
+	PlaceholderExecutable     = "executable"      // output_file nếu ngôn ngữ có biên dịch, nếu không thì source_file
+	PlaceholderTempDir        = "temp_dir"        // Thư mục tạm của submission
+	PlaceholderMemoryLimitKb  = "memory_limit_kb" // Giới hạn bộ nhớ của submission (KB), ví dụ cho -Xmx của JVM
+	PlaceholderMemoryLimitMb  = "memory_limit_mb" // Giới hạn bộ nhớ của submission (MB, làm tròn xuống)
+	PlaceholderTimeLimitMs    = "time_limit_ms"   // Giới hạn thời gian của submission (ms)
+	PlaceholderFlags          = "flags"           // Các flag của submission; phải đứng riêng thành một argument
+)
+
+// knownPlaceholders là tập placeholder hợp lệ; placeholder khác bị báo lỗi khi load config.
+var knownPlaceholders = map[string]bool{
+	PlaceholderSourceFile:     true,
+	PlaceholderSourceBasename: true,
+	PlaceholderOutputFile:     true,
+	PlaceholderExecutable:     true,
+	PlaceholderTempDir:        true,
+	PlaceholderMemoryLimitKb:  true,
+	PlaceholderMemoryLimitMb:  true,
+	PlaceholderTimeLimitMs:    true,
+	PlaceholderFlags:          true,
+}
+
+// stepSeparator tách một lệnh thành nhiều bước, ví dụ "javac Main.java && jar cf main.jar Main.class".
+// Các bước được chạy lần lượt (không qua shell), dừng ở bước đầu tiên thất bại.
+const stepSeparator = "&&"
+
+// Template là một lệnh đã được tách thành các bước và các argument.
+// Cú pháp giống shell ở mức tối thiểu: khoảng trắng tách argument, '...' giữ nguyên nội dung,
+// "..." cho phép \", \\, \{ và \}, dấu \ bên ngoài dấu nháy escape ký tự tiếp theo, và && (không nằm
+// trong dấu nháy) tách các bước. Placeholder {name} được nhận ra khi tách (bên ngoài dấu nháy hoặc trong "..."),
+// và được thay sau khi tách, nên đường dẫn có khoảng trắng vẫn là một argument. Trong '...' và sau \,
+// dấu { và } là ký tự thường, ví dụ \{ hoặc '{1..3}'.
+type Template struct {
+	raw   string
+	steps [][]argument
+}
+
+// argument là một argument của lệnh: các đoạn text và placeholder nối liền nhau.
+type argument []segment
+
+// segment là một đoạn text, hoặc một placeholder nếu placeholder khác rỗng.
+type segment struct {
+	text        string
+	placeholder string
+}
+
+// isFlags cho biết argument chỉ gồm {flags}, được thay bằng 0..n argument.
+func (a argument) isFlags() bool {
+	return len(a) == 1 && a[0].placeholder == PlaceholderFlags
+}
+
+// Vars là giá trị của các placeholder khi render một Template.
+type Vars struct {
+	SourceFile    string
+	OutputFile    string // Rỗng với ngôn ngữ thông dịch
+	TempDir       string
+	MemoryLimitKb int
+	TimeLimitMs   int
+	Flags         []string
+}
+
+// ParseTemplate tách s thành các bước và kiểm tra các placeholder.
+func ParseTemplate(s string) (*Template, error) {
+	words, err := splitWords(s)
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{raw: s}
+	var step []argument
+	for _, w := range words {
+		if w.separator {
+			if len(step) == 0 {
+				return nil, fmt.Errorf("empty step before %q", stepSeparator)
+			}
+			t.steps = append(t.steps, step)
+			step = nil
+			continue
+		}
+		step = append(step, w.arg)
+	}
+	if len(step) == 0 {
+		if len(t.steps) > 0 {
+			return nil, fmt.Errorf("empty step after %q", stepSeparator)
+		}
+		return nil, fmt.Errorf("empty command")
+	}
+	t.steps = append(t.steps, step)
+	return t, nil
+}
+
+// String trả về lệnh gốc của template.
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Steps trả về số bước của template.
+func (t *Template) Steps() int {
+	return len(t.steps)
+}
+
+// Render thay placeholder bằng giá trị trong vars và trả về argv của từng bước.
+func (t *Template) Render(vars Vars) [][]string {
+	values := vars.values()
+	steps := make([][]string, 0, len(t.steps))
+	for _, step := range t.steps {
+		argv := make([]string, 0, len(step))
+		for _, arg := range step {
+			if arg.isFlags() {
+				argv = append(argv, vars.Flags...) // 0..n argument
+				continue
+			}
+			var b strings.Builder
+			for _, seg := range arg {
+				if seg.placeholder != "" {
+					b.WriteString(values[seg.placeholder])
+				} else {
+					b.WriteString(seg.text)
+				}
+			}
+			argv = append(argv, b.String())
+		}
+		steps = append(steps, argv)
+	}
+	return steps
+}
+
+func (v Vars) values() map[string]string {
+	executable := v.OutputFile
+	if executable == "" {
+		executable = v.SourceFile
+	}
+	base := filepath.Base(v.SourceFile)
+	return map[string]string{
+		PlaceholderSourceFile:     v.SourceFile,
+		PlaceholderSourceBasename: strings.TrimSuffix(base, filepath.Ext(base)),
+		PlaceholderOutputFile:     v.OutputFile,
+		PlaceholderExecutable:     executable,
+		PlaceholderTempDir:        v.TempDir,
+		PlaceholderMemoryLimitKb:  strconv.Itoa(v.MemoryLimitKb),
+		PlaceholderMemoryLimitMb:  strconv.Itoa(v.MemoryLimitKb / 1024),
+		PlaceholderTimeLimitMs:    strconv.Itoa(v.TimeLimitMs),
+	}
+}
+
+type word struct {
+	arg       argument
+	separator bool // && không nằm trong dấu nháy
+}
+
+// splitWords tách s thành các argument theo quy tắc quoting của Template và nhận ra các placeholder.
+func splitWords(s string) ([]word, error) {
+	var words []word
+	var arg argument
+	var text strings.Builder // Text chưa được đưa vào arg
+	inWord := false          // Đang có một argument (có thể rỗng, ví dụ "")
+	quoted := false          // argument hiện tại có phần nằm trong dấu nháy hoặc được escape
+	endText := func() {
+		if text.Len() > 0 {
+			arg = append(arg, segment{text: text.String()})
+			text.Reset()
+		}
+	}
+	flush := func() error {
+		if !inWord {
+			return nil
+		}
+		endText()
+		for _, seg := range arg {
+			if seg.placeholder == PlaceholderFlags && !arg.isFlags() {
+				return fmt.Errorf("{%s} must be a separate argument in %q", PlaceholderFlags, s)
+			}
+		}
+		separator := !quoted && len(arg) == 1 && arg[0].text == stepSeparator
+		if arg == nil {
+			arg = argument{} // "" là một argument rỗng
+		}
+		words = append(words, word{arg: arg, separator: separator})
+		arg = nil
+		inWord, quoted = false, false
+		return nil
+	}
+	// placeholder đọc {name} bắt đầu tại s[i] và trả về vị trí của '}'.
+	placeholder := func(i int, inQuotes bool) (int, error) {
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return 0, fmt.Errorf("unterminated placeholder in %q (write \\{ for a literal brace)", s)
+		}
+		name := s[i+1 : i+end]
+		if !knownPlaceholders[name] {
+			return 0, fmt.Errorf("unknown placeholder {%s} in %q (write \\{ for a literal brace)", name, s)
+		}
+		if name == PlaceholderFlags && inQuotes {
+			return 0, fmt.Errorf("{%s} cannot be quoted in %q: it expands to separate arguments", PlaceholderFlags, s)
+		}
+		endText()
+		arg = append(arg, segment{placeholder: name})
+		inWord = true
+		return i + end, nil
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in %q", s)
+			}
+			text.WriteString(s[i+1 : i+1+end])
+			inWord, quoted = true, true
+			i += end + 1
+		case c == '"':
+			inWord, quoted = true, true
+			closed := false
+			for i++; i < len(s); i++ {
+				if s[i] == '"' {
+					closed = true
+					break
+				}
+				if s[i] == '{' {
+					var err error
+					if i, err = placeholder(i, true); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\{}`, s[i+1]) >= 0 {
+					i++
+				}
+				text.WriteByte(s[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated double quote in %q", s)
+			}
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("trailing backslash in %q", s)
+			}
+			i++
+			text.WriteByte(s[i])
+			inWord, quoted = true, true
+		case c == '{':
+			var err error
+			if i, err = placeholder(i, false); err != nil {
+				return nil, err
+			}
+		default:
+			text.WriteByte(c)
+			inWord = true
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
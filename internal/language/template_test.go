@@ -0,0 +1,119 @@
+package language
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateRender(t *testing.T) {
+	vars := Vars{
+		SourceFile:    "/tmp/sub 1/Main.java",
+		OutputFile:    "/tmp/sub 1/main",
+		TempDir:       "/tmp/sub 1",
+		MemoryLimitKb: 262144,
+		TimeLimitMs:   1500,
+		Flags:         []string{"-O2", "-std=c++17"},
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     [][]string
+	}{
+		{"plain", "g++ -o main main.cpp", [][]string{{"g++", "-o", "main", "main.cpp"}}},
+		{"extra whitespace", "  g++\t-O2 \n main.cpp  ", [][]string{{"g++", "-O2", "main.cpp"}}},
+		{"path with spaces stays one argument", "{executable}", [][]string{{"/tmp/sub 1/main"}}},
+		{"placeholders", "java -Xmx{memory_limit_mb}m -cp {temp_dir} {source_basename}",
+			[][]string{{"java", "-Xmx256m", "-cp", "/tmp/sub 1", "Main"}}},
+		{"all placeholders", "{source_file} {output_file} {memory_limit_kb} {time_limit_ms}",
+			[][]string{{"/tmp/sub 1/Main.java", "/tmp/sub 1/main", "262144", "1500"}}},
+		{"flags splat", "g++ {flags} -o {output_file}", [][]string{{"g++", "-O2", "-std=c++17", "-o", "/tmp/sub 1/main"}}},
+
+		{"single quotes", `go build -ldflags '-s -w'`, [][]string{{"go", "build", "-ldflags", "-s -w"}}},
+		{"double quotes", `go build -ldflags "-s -w"`, [][]string{{"go", "build", "-ldflags", "-s -w"}}},
+		{"adjacent quotes join", `a'b c'"d e"f`, [][]string{{"ab cd ef"}}},
+		{"empty quotes", `echo "" ''`, [][]string{{"echo", "", ""}}},
+		{"double quote escapes", `echo "a\"b\\c\d"`, [][]string{{"echo", `a"b\c\d`}}},
+		{"backslash escapes", `echo a\ b \'c`, [][]string{{"echo", "a b", "'c"}}},
+		{"placeholder in double quotes", `cc "-DDIR={temp_dir}"`, [][]string{{"cc", "-DDIR=/tmp/sub 1"}}},
+
+		{"escaped brace", `echo \{source_file} x\}`, [][]string{{"echo", "{source_file}", "x}"}}},
+		{"escaped brace in double quotes", `echo "\{flags\}"`, [][]string{{"echo", "{flags}"}}},
+		{"single quotes are literal", `bash -c 'echo {1..3} {flags}'`, [][]string{{"bash", "-c", "echo {1..3} {flags}"}}},
+		{"lone closing brace", "echo }", [][]string{{"echo", "}"}}},
+
+		{"steps", "javac {source_file} && jar cf main.jar Main.class",
+			[][]string{{"javac", "/tmp/sub 1/Main.java"}, {"jar", "cf", "main.jar", "Main.class"}}},
+		{"quoted separator is an argument", `echo '&&' "&&" \&& a&&b`, [][]string{{"echo", "&&", "&&", "&&", "a&&b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("ParseTemplate(%q): %v", tt.template, err)
+			}
+			if got := tmpl.Render(vars); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Render(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+			if tmpl.Steps() != len(tt.want) {
+				t.Errorf("Steps() = %d, want %d", tmpl.Steps(), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestTemplateExecutableWithoutOutput(t *testing.T) {
+	tmpl, err := ParseTemplate("python3 {executable}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := tmpl.Render(Vars{SourceFile: "/tmp/s/main.py"})
+	if want := [][]string{{"python3", "/tmp/s/main.py"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestTemplateNoFlags(t *testing.T) {
+	tmpl, err := ParseTemplate("g++ {flags} main.cpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tmpl.Render(Vars{}), [][]string{{"g++", "main.cpp"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		message  string
+	}{
+		{"empty", "", "empty command"},
+		{"blank", "   ", "empty command"},
+		{"empty step before", "&& g++ main.cpp", "empty step before"},
+		{"empty step between", "a && && b", "empty step before"},
+		{"empty step after", "g++ main.cpp &&", "empty step after"},
+		{"unterminated single quote", "echo 'abc", "unterminated single quote"},
+		{"unterminated double quote", `echo "abc`, "unterminated double quote"},
+		{"trailing backslash", `echo \`, "trailing backslash"},
+		{"unknown placeholder", "gcc {source}", "unknown placeholder {source}"},
+		{"unknown placeholder in double quotes", `gcc "{source}"`, "unknown placeholder {source}"},
+		{"brace expansion needs escape", "echo {1..3}", "unknown placeholder {1..3}"},
+		{"unterminated placeholder", "gcc {source_file", "unterminated placeholder"},
+		{"flags inside argument", "gcc -f{flags}", "must be a separate argument"},
+		{"flags next to placeholder", "gcc {flags}{source_file}", "must be a separate argument"},
+		{"flags in double quotes", `gcc "{flags}"`, "cannot be quoted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTemplate(tt.template)
+			if err == nil {
+				t.Fatalf("ParseTemplate(%q) returned no error", tt.template)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("ParseTemplate(%q) = %v, want it to contain %q", tt.template, err, tt.message)
+			}
+		})
+	}
+}
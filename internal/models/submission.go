@@ -1,18 +1,45 @@
 package models
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+)
+
 type TestcaseStatus string
 
 const (
-	Success             TestcaseStatus = "success"
-	CompileError        TestcaseStatus = "compile_error"
-	RuntimeError        TestcaseStatus = "runtime_error"
-	WrongAnswer         TestcaseStatus = "wrong_answer"
-	TimeLimitExceeded   TestcaseStatus = "time_limit_exceeded"
-	MemoryLimitExceeded TestcaseStatus = "memory_limit_exceeded"
-	Running             TestcaseStatus = "running"
-	None                TestcaseStatus = "none"
+	Success      TestcaseStatus = "success"
+	CompileError TestcaseStatus = "compile_error"
+	// CompileTimeout và CompileMemoryLimitExceeded là lỗi biên dịch do sandbox phát hiện
+	// (trình biên dịch chạy quá thời gian / bộ nhớ cho phép), khác với lỗi cú pháp thông thường.
+	CompileTimeout             TestcaseStatus = "compile_timeout"
+	CompileMemoryLimitExceeded TestcaseStatus = "compile_memory_limit_exceeded"
+	RuntimeError               TestcaseStatus = "runtime_error"
+	WrongAnswer                TestcaseStatus = "wrong_answer"
+	TimeLimitExceeded          TestcaseStatus = "time_limit_exceeded"
+	MemoryLimitExceeded        TestcaseStatus = "memory_limit_exceeded"
+	Running                    TestcaseStatus = "running"
+	None                       TestcaseStatus = "none"
+	// Rejected: submission không hợp lệ (language ID không được hỗ trợ, flag không được phép...), không được chạy.
+	Rejected TestcaseStatus = "rejected"
+	// PresentationError: checker chấp nhận đáp án nhưng sai định dạng output (testlib exit code 2).
+	PresentationError TestcaseStatus = "presentation_error"
+	// Skipped: test case không được chạy vì kết quả của subtask đã được quyết định bởi một test case sai trước đó,
+	// hoặc vì submission đã dừng sớm theo settings.stopOnFirstFailure.
+	Skipped TestcaseStatus = "skipped"
+	// InternalError: lỗi của runner/sandbox/checker, không phải lỗi của thí sinh.
+	InternalError TestcaseStatus = "internal_error"
+	// Cancelled: submission bị hủy qua subject submission.cancel trước khi test case này chạy xong.
+	Cancelled TestcaseStatus = "cancelled"
+)
+
+// Giá trị của SubmissionResult.CompileCache, cho biết binary lấy từ compile cache hay vừa biên dịch.
+const (
+	CompileCacheHit  = "hit"
+	CompileCacheMiss = "miss"
 )
-const InternalError = ""
 
 type Submission struct {
 	ID              string             `json:"id"`
@@ -22,14 +49,94 @@ type Submission struct {
 	MemoryLimitInKb int                `json:"memoryLimitInKb"`
 	TestCases       []TestCase         `json:"testCases"`
 	Settings        SubmissionSettings `json:"settings"`
+	// LanguageID tham chiếu tới một ngôn ngữ trong registry của runner (config "languages").
+	// Client cũ có thể gửi language.id thay thế; compileCommand/runCommand do client gửi luôn bị bỏ qua.
+	LanguageID string `json:"languageId"`
+	// CompileFlags là các flag biên dịch tùy chọn, phải nằm trong allowedFlags của ngôn ngữ.
+	CompileFlags []string `json:"compileFlags,omitempty"`
+	// Checker, nếu có, thay cho việc so sánh output với ExpectOutput (special judge).
+	Checker *Checker `json:"checker,omitempty"`
+	// Interactor, nếu có, biến submission thành bài tương tác: interactor chạy song song với chương trình
+	// của thí sinh, stdout của bên này nối vào stdin của bên kia. Không dùng chung với Checker.
+	Interactor *Checker `json:"interactor,omitempty"`
+	// Subtasks nhóm các test case để tính điểm. Rỗng = không tính điểm, mọi test case được chạy như cũ.
+	Subtasks []Subtask `json:"subtasks,omitempty"`
+	// Raw là payload gốc nhận từ NATS, được giữ nguyên khi submission bị chuyển vào dead-letter.
+	Raw []byte `json:"-"`
+}
+
+// Các cách tính điểm của một subtask (Subtask.Scoring).
+const (
+	ScoringAllOrNothing = "all_or_nothing" // Được Score nếu mọi test case đúng, nếu không thì 0 (mặc định)
+	ScoringMin          = "min"            // Điểm thấp nhất trong các test case (test sai = 0, test đúng = Points hoặc Score)
+	ScoringSum          = "sum"            // Tổng Points của các test case đúng; không có Points thì chia đều Score
+)
+
+// Subtask là một nhóm test case được tính điểm chung. Với "all_or_nothing" và "min", các test case
+// còn lại của nhóm bị bỏ qua (Skipped) sau test case sai đầu tiên vì điểm của nhóm đã được quyết định.
+type Subtask struct {
+	ID          string   `json:"id"`
+	Score       float64  `json:"score"`
+	Scoring     string   `json:"scoring,omitempty"`
+	TestCaseIDs []string `json:"testCaseIds"`
+}
+
+// Checker là special judge kiểu testlib: được chạy với 3 tham số input, output của thí sinh
+// và đáp án (ExpectOutput), exit code 0 = đúng, 1 = sai, 2 = sai định dạng.
+// Dùng ID cho checker đã đăng ký trên runner, hoặc LanguageID + Code để runner tự biên dịch.
+type Checker struct {
+	ID         string `json:"id,omitempty"`
+	LanguageID string `json:"languageId,omitempty"`
+	Code       string `json:"code,omitempty"`
+}
+
+// LanguageKey trả về language ID của submission, ưu tiên languageId rồi tới language.id (client cũ).
+func (s Submission) LanguageKey() string {
+	if s.LanguageID != "" {
+		return s.LanguageID
+	}
+	return s.Language.ID
+}
+
+// submissionIDPattern là các submission ID hợp lệ. ID được dùng làm tên thư mục tạm trong sandboxBaseDir
+// (thư mục bị xóa khi submission xong), nên không được chứa "/" hay "..".
+var submissionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// ValidateSubmissionID trả về lỗi nếu id không chỉ gồm chữ, số, '_' và '-' (tối đa 128 ký tự).
+func ValidateSubmissionID(id string) error {
+	if !submissionIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid submission id %q: only letters, digits, '_' and '-' are allowed (at most 128)", id)
+	}
+	return nil
+}
+
+// NewSubmissionID tạo ID ngẫu nhiên (32 ký tự hex, hợp lệ với ValidateSubmissionID) cho submission không có ID.
+func NewSubmissionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate submission id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
 }
 
 type SubmissionSettings struct {
 	WithTrim          bool `json:"withTrim"`
 	WithCaseSensitive bool `json:"withCaseSensitive"`
 	WithWhitespace    bool `json:"withWhitespace"`
+	// ComparisonMode chọn cách so sánh output: "exact", "lines", "tokens", "ignore_trailing_blank_lines" hoặc "numeric".
+	// Rỗng = "tokens" nếu WithWhitespace, nếu không thì "lines".
+	ComparisonMode string `json:"comparisonMode,omitempty"`
+	// AbsoluteEpsilon/RelativeEpsilon là sai số cho phép của chế độ "numeric": một số được chấp nhận nếu
+	// lệch không quá AbsoluteEpsilon hoặc RelativeEpsilon*|expected|. Cả hai bằng 0 = dùng 1e-6.
+	AbsoluteEpsilon float64 `json:"absoluteEpsilon,omitempty"`
+	RelativeEpsilon float64 `json:"relativeEpsilon,omitempty"`
+	// StopOnFirstFailure: "never" (mặc định), "any" (dừng ở test case sai đầu tiên) hoặc "fatal"
+	// (chỉ dừng khi TLE, MLE hoặc RE). Các test case còn lại được báo là Skipped.
+	StopOnFirstFailure string `json:"stopOnFirstFailure,omitempty"`
 }
 
+// Language là thông tin ngôn ngữ mà client cũ gửi kèm submission. Runner chỉ đọc ID,
+// cách biên dịch và chạy luôn lấy từ registry (language.Registry).
 type Language struct {
 	ID             string `json:"id"`
 	SourceFile     string ` json:"sourceFile"`
@@ -42,6 +149,8 @@ type TestCase struct {
 	ID           string `json:"id"`
 	Input        string `json:"input"`
 	ExpectOutput string `json:"expectOutput"`
+	// Points là điểm của test case trong subtask tính điểm "sum" hoặc "min".
+	Points float64 `json:"points,omitempty"`
 }
 
 type SubmissionResult struct {
@@ -52,4 +161,116 @@ type SubmissionResult struct {
 	MemoryUsedInKb int            `json:"memoryUsedInKb"`
 	Output         string         `json:"output"`
 	Error          string         `json:"error"`
+	CompileCache   string         `json:"compileCache,omitempty"`   // "hit" / "miss"; rỗng nếu không biên dịch hoặc cache bị tắt
+	CheckerMessage string         `json:"checkerMessage,omitempty"` // Thông báo của checker (stderr), nếu submission dùng checker
+	Transcript     string         `json:"transcript,omitempty"`     // Dữ liệu trao đổi với interactor (đã cắt), nếu là bài tương tác
+}
+
+// SubmissionScore là message cuối cùng của submission có subtask, chứa điểm từng subtask và tổng điểm.
+type SubmissionScore struct {
+	SubmissionID string         `json:"submissionId"`
+	Score        float64        `json:"score"`
+	MaxScore     float64        `json:"maxScore"`
+	Subtasks     []SubtaskScore `json:"subtasks"`
+}
+
+type SubtaskScore struct {
+	SubtaskID string         `json:"subtaskId"`
+	Score     float64        `json:"score"`
+	MaxScore  float64        `json:"maxScore"`
+	Status    TestcaseStatus `json:"status"` // Success, hoặc status của test case sai đầu tiên
+	Passed    int            `json:"passed"`
+	Total     int            `json:"total"`
+}
+
+// SubmissionSummary là message cuối cùng của mọi submission, được publish sau khi ProcessSubmission kết thúc
+// (kể cả khi bị từ chối, biên dịch lỗi hay lỗi nội bộ), để consumer biết submission đã xong.
+type SubmissionSummary struct {
+	SubmissionID  string         `json:"submissionId"`
+	Verdict       TestcaseStatus `json:"verdict"` // Success, hoặc status của test case sai đầu tiên / lỗi biên dịch / lỗi chung
+	Passed        int            `json:"passed"`
+	Total         int            `json:"total"`
+	MaxTimeInMs   int            `json:"maxTimeInMs"`
+	MaxMemoryInKb int            `json:"maxMemoryInKb"`
+	CompileLog    string         `json:"compileLog,omitempty"`
+	CompileCache  string         `json:"compileCache,omitempty"`
+	DurationInMs  int64          `json:"durationInMs"` // Thời gian xử lý submission trên runner
+	Error         string         `json:"error,omitempty"`
+}
+
+// ProgressStage là giai đoạn xử lý của submission trong SubmissionProgress.
+type ProgressStage string
+
+const (
+	StageReceived  ProgressStage = "received"  // Runner đã nhận submission
+	StageWaiting   ProgressStage = "waiting"   // Đang chờ slot (runner.maxConcurrentJobs đã đầy)
+	StageStarted   ProgressStage = "started"   // Đã có slot, QueueWaitInMs là thời gian chờ
+	StageCompiling ProgressStage = "compiling" // Đang biên dịch
+	StageCompiled  ProgressStage = "compiled"  // Biên dịch thành công
+	StageRunning   ProgressStage = "running"   // Đang chạy test case thứ TestCaseIndex
+	StageFinished  ProgressStage = "finished"  // Đã xong, Status là verdict của submission
+)
+
+// SubmissionProgress là sự kiện tiến độ, publish lên subject "submission.progress.<submissionId>".
+type SubmissionProgress struct {
+	SubmissionID   string         `json:"submissionId"`
+	Stage          ProgressStage  `json:"stage"`
+	Status         TestcaseStatus `json:"status,omitempty"`        // Running khi đang chạy test case, verdict khi finished
+	TestCaseID     string         `json:"testCaseId,omitempty"`    // Test case đang chạy (stage "running")
+	TestCaseIndex  int            `json:"testCaseIndex,omitempty"` // Thứ tự của test case, bắt đầu từ 1
+	TotalTestCases int            `json:"totalTestCases"`
+	QueueWaitInMs  int64          `json:"queueWaitInMs,omitempty"`
+	Timestamp      int64          `json:"timestamp"` // Unix milliseconds
+}
+
+// Lý do một message bị chuyển vào dead-letter (DeadLetter.Stage).
+const (
+	DeadLetterDecode        = "decode"         // Message không phải JSON submission hợp lệ
+	DeadLetterValidation    = "validation"     // Submission bị từ chối (language không hỗ trợ, settings sai...)
+	DeadLetterMaxDeliveries = "max_deliveries" // Đã được giao quá nats.jetstream.maxDeliver lần (runner crash khi xử lý)
+)
+
+// DeadLetter là message không xử lý được, được publish lên nats.deadLetterSubject kèm payload gốc
+// để có thể xem lại và gửi lại (cmd/deadletter).
+type DeadLetter struct {
+	SubmissionID string `json:"submissionId,omitempty"` // Rỗng nếu payload không decode được
+	Stage        string `json:"stage"`
+	Reason       string `json:"reason"`
+	Subject      string `json:"subject"`              // Subject gốc của message, dùng khi gửi lại
+	Deliveries   uint64 `json:"deliveries,omitempty"` // Số lần JetStream đã giao message
+	Payload      []byte `json:"payload"`              // Payload gốc (base64 trong JSON)
+	FailedAt     int64  `json:"failedAt"`             // Unix milliseconds
+}
+
+// SubmissionCancel là message yêu cầu hủy một submission, gửi lên subject submission.cancel.
+// Mọi runner đều nhận message này; runner đang giữ submission sẽ dừng nó, các runner khác ghi nhớ
+// để bỏ qua submission nếu nhận được sau đó. Chỉ submission được đưa vào hàng đợi trước RequestedAt
+// bị hủy, nên submission chấm lại với cùng ID sau đó vẫn được chạy.
+type SubmissionCancel struct {
+	SubmissionID string `json:"submissionId"`
+	Reason       string `json:"reason,omitempty"`
+	// RequestedAt là thời điểm gửi yêu cầu (Unix milliseconds). 0 = thời điểm runner nhận yêu cầu.
+	RequestedAt int64 `json:"requestedAt,omitempty"`
+}
+
+// RunSubmissionRequest là request trên subject submission.run (request-reply): một submission bình thường
+// kèm thời hạn. Runner trả lời bằng RunSubmissionResponse chứa mọi kết quả, thay vì publish từng kết quả.
+type RunSubmissionRequest struct {
+	Submission
+	// TimeoutInMs là thời hạn để có câu trả lời, tính cả thời gian chờ slot; hết hạn thì các test case
+	// chưa chạy xong được báo là Cancelled. 0 = mặc định của runner.
+	TimeoutInMs int `json:"timeoutInMs,omitempty"`
+}
+
+// RunSubmissionResponse là câu trả lời của submission.run.
+type RunSubmissionResponse struct {
+	SubmissionID string             `json:"submissionId"`
+	Summary      SubmissionSummary  `json:"summary"`
+	Results      []SubmissionResult `json:"results"`
+	Score        *SubmissionScore   `json:"score,omitempty"` // Chỉ có khi submission có subtask
+	// Error là lỗi của chính request (không decode được, reply quá lớn...), không phải lỗi của submission.
+	Error string `json:"error,omitempty"`
+	// Busy: runner không có slot trống nên submission không được chạy; gửi lại request sau
+	// (với NATS, request gửi lại có thể tới runner khác đang rảnh trong queue group).
+	Busy bool `json:"busy,omitempty"`
 }
@@ -0,0 +1,48 @@
+package models
+
+import "testing"
+
+func TestValidateSubmissionID(t *testing.T) {
+	long := make([]byte, 129)
+	for i := range long {
+		long[i] = 'a'
+	}
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"e72e9906-4c8e-4834-b057-543336705740", true},
+		{"run_1", true},
+		{string(long[:128]), true},
+		{"", false},
+		{string(long), false},
+		{"..", false},
+		{"../x", false},
+		{"a/b", false},
+		{"/etc", false},
+		{"a.b", false},
+		{"a b", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateSubmissionID(tt.id); (err == nil) != tt.valid {
+			t.Errorf("ValidateSubmissionID(%q) = %v, want valid = %v", tt.id, err, tt.valid)
+		}
+	}
+}
+
+func TestNewSubmissionID(t *testing.T) {
+	a, err := NewSubmissionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewSubmissionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSubmissionID(a); err != nil {
+		t.Errorf("generated id is not valid: %v", err)
+	}
+	if len(a) != 32 || a == b {
+		t.Errorf("NewSubmissionID() = %q, %q, want two distinct 32-character ids", a, b)
+	}
+}
@@ -0,0 +1,249 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	defaultAckWait     = 60 * time.Second // Dùng khi nats.jetstream.ackWaitSec không được đặt
+	resultFlushTimeout = 10 * time.Second // Thời gian chờ server nhận hết kết quả trước khi ack
+	jetStreamFetchWait = 5 * time.Second  // Thời gian một pull request chờ message; worker kiểm tra lệnh dừng sau mỗi lần
+)
+
+// ConsumeJetStream nhận submission qua durable pull consumer của JetStream thay cho QueueSubscribe.
+// Mỗi message chỉ được ack sau khi HandleSubmission trả về và kết quả đã được flush lên server; trong lúc
+// xử lý, runner gửi InProgress định kỳ để message không bị giao lại. Nếu runner crash trước khi ack,
+// JetStream giao lại message sau AckWait, tối đa MaxDeliver lần.
+//
+// Giống SubscribeToSubmissions, mỗi trong số workers worker chỉ kéo message tiếp theo khi đã xử lý xong
+// message trước, nên runner giữ tối đa workers message chưa ack và không đệm message nào trong bộ nhớ;
+// message còn lại nằm trong stream cho các runner khác. workers <= 0 nhận message liên tục, không giới hạn.
+// cfg.MaxAckPending là giới hạn của cả consumer (chung cho mọi runner), không phải của runner này.
+func (s *Subscriber) ConsumeJetStream(ctx context.Context, cfg config.JetStreamConfig, workers int) (*Intake, error) {
+	subject := s.subjects.SubmissionCreated
+	js, err := jetstream.New(s.nc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	err = ensureStream(ctx, js, jetstream.StreamConfig{
+		Name:      cfg.Stream,
+		Subjects:  []string{subject},
+		Retention: jetstream.WorkQueuePolicy, // Message bị xóa khi đã được ack
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureDeadLetterStream(ctx, js, cfg.DeadLetterStream); err != nil {
+		return nil, err
+	}
+
+	ackWait := time.Duration(cfg.AckWaitSec) * time.Second
+	if ackWait <= 0 {
+		ackWait = defaultAckWait
+	}
+	inProgressEvery := time.Duration(cfg.InProgressEverySec) * time.Second
+	if inProgressEvery <= 0 || inProgressEvery >= ackWait {
+		inProgressEvery = ackWait / 3
+	}
+	consumerCfg := jetstream.ConsumerConfig{
+		Durable:       cfg.Durable,
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       ackWait,
+	}
+	if cfg.MaxDeliver > 0 {
+		// Thêm một lần giao để lần cuối cùng message được chuyển vào dead-letter thay vì bị bỏ im lặng.
+		consumerCfg.MaxDeliver = cfg.MaxDeliver + 1
+	}
+	if cfg.MaxAckPending > 0 {
+		consumerCfg.MaxAckPending = cfg.MaxAckPending
+	}
+	consumer, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, consumerCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer %s: %w", cfg.Durable, err)
+	}
+
+	workerCtx, cancel := context.WithCancel(context.Background())
+	in := &Intake{mode: IntakeJetStream, cancel: cancel, workers: max(workers, 0), consumer: consumer}
+	if workers <= 0 {
+		consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+			// Callback của Consume được gọi tuần tự, nên mỗi submission được xử lý trong goroutine riêng.
+			in.wg.Add(1)
+			go func() {
+				defer in.wg.Done()
+				s.handleJetStreamMessage(in, msg, inProgressEvery, cfg.MaxDeliver)
+			}()
+		}, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+			log.Printf("JetStream consume error: %v", err)
+		}))
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to start consuming: %w", err)
+		}
+		in.consumeCtx = consumeCtx
+	} else {
+		for range workers {
+			in.wg.Add(1)
+			go s.jetStreamWorker(workerCtx, in, consumer, inProgressEvery, cfg.MaxDeliver)
+		}
+	}
+
+	log.Printf("Consuming NATS subject %s via JetStream stream %s, durable consumer %s (ackWait %s, maxDeliver %d, workers %d, consumer maxAckPending %d)",
+		subject, cfg.Stream, cfg.Durable, ackWait, cfg.MaxDeliver, workers, cfg.MaxAckPending)
+	return in, nil
+}
+
+// jetStreamWorker kéo một message, xử lý và ack xong rồi mới kéo message tiếp theo.
+func (s *Subscriber) jetStreamWorker(ctx context.Context, in *Intake, consumer jetstream.Consumer, inProgressEvery time.Duration, maxDeliver int) {
+	defer in.wg.Done()
+	for ctx.Err() == nil {
+		msg, err := consumer.Next(jetstream.FetchMaxWait(jetStreamFetchWait))
+		if err != nil {
+			if !errors.Is(err, nats.ErrTimeout) {
+				log.Printf("Error fetching from JetStream consumer: %v", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(resubscribeDelay):
+				}
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			// Runner đang dừng: trả message lại để runner khác nhận ngay thay vì chờ AckWait.
+			if err := msg.Nak(); err != nil {
+				log.Printf("Error returning message to JetStream: %v", err)
+			}
+			return
+		}
+		s.handleJetStreamMessage(in, msg, inProgressEvery, maxDeliver)
+	}
+}
+
+// handleJetStreamMessage xử lý một message, gửi InProgress trong lúc chạy và ack khi đã xong.
+func (s *Subscriber) handleJetStreamMessage(in *Intake, msg jetstream.Msg, inProgressEvery time.Duration, maxDeliver int) {
+	deliveries := uint64(1)
+	queuedAt := time.Now()
+	if meta, err := msg.Metadata(); err == nil {
+		deliveries = meta.NumDelivered
+		queuedAt = meta.Timestamp // Thời điểm message được lưu vào stream, giống nhau ở mọi lần giao lại
+	}
+	log.Printf("Received a message on subject: %s via JetStream (delivery %d)", msg.Subject(), deliveries)
+
+	var sub models.Submission
+	if err := json.Unmarshal(msg.Data(), &sub); err != nil {
+		// Giao lại cũng không decode được, nên chuyển thẳng vào dead-letter.
+		log.Printf("Error unmarshalling submission data: %v. Message data: %s", err, string(msg.Data()))
+		s.deadLetter(msg, models.DeadLetter{Stage: models.DeadLetterDecode, Reason: err.Error(), Deliveries: deliveries})
+		return
+	}
+	sub.Raw = msg.Data()
+	if maxDeliver > 0 && deliveries > uint64(maxDeliver) {
+		// Các lần trước đều không ack được (thường là runner crash khi xử lý), không chạy lại nữa.
+		log.Printf("SubmissionID %s exceeded %d deliveries, moving it to dead-letter", sub.ID, maxDeliver)
+		s.deadLetter(msg, models.DeadLetter{
+			SubmissionID: sub.ID,
+			Stage:        models.DeadLetterMaxDeliveries,
+			Reason:       fmt.Sprintf("not acknowledged after %d deliveries", maxDeliver),
+			Deliveries:   deliveries,
+		})
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(inProgressEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := msg.InProgress(); err != nil {
+					log.Printf("Error sending InProgress for SubmissionID %s: %v", sub.ID, err)
+				}
+			}
+		}
+	}()
+	s.handle(in, sub, queuedAt)
+	close(done)
+
+	// Kết quả được publish bằng core NATS; chỉ ack khi chắc chắn server đã nhận hết,
+	// nếu không thì để JetStream giao lại (consumer có thể nhận kết quả trùng, không bị mất).
+	if err := s.nc.FlushTimeout(resultFlushTimeout); err != nil {
+		log.Printf("Error flushing results of SubmissionID %s, leaving message unacknowledged: %v", sub.ID, err)
+		return
+	}
+	if err := msg.Ack(); err != nil {
+		log.Printf("Error acknowledging SubmissionID %s: %v", sub.ID, err)
+		return
+	}
+	log.Printf("Acknowledged SubmissionID %s", sub.ID)
+}
+
+// deadLetter chuyển message vào dead-letter rồi Term để JetStream không giao lại.
+// Nếu không publish được dead-letter thì để message được giao lại, tránh mất dữ liệu.
+func (s *Subscriber) deadLetter(msg jetstream.Msg, dl models.DeadLetter) {
+	dl.Subject = msg.Subject()
+	dl.Payload = msg.Data()
+	if err := s.publisher.PublishDeadLetter(dl); err != nil {
+		return
+	}
+	if err := s.nc.FlushTimeout(resultFlushTimeout); err != nil {
+		log.Printf("Error flushing dead letter, leaving message unacknowledged: %v", err)
+		return
+	}
+	if err := msg.Term(); err != nil {
+		log.Printf("Error terminating message: %v", err)
+	}
+}
+
+// EnsureDeadLetterStream tạo stream cfg.DeadLetterStream cho subject dead-letter nếu chưa có, để cmd/deadletter
+// xem và gửi lại được các message không xử lý được. ConsumeJetStream tự gọi hàm này; với core NATS cần gọi riêng.
+// Không làm gì nếu cfg.DeadLetterStream rỗng.
+func (s *Subscriber) EnsureDeadLetterStream(ctx context.Context, cfg config.JetStreamConfig) error {
+	if cfg.DeadLetterStream == "" {
+		return nil
+	}
+	js, err := jetstream.New(s.nc)
+	if err != nil {
+		return fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	return s.ensureDeadLetterStream(ctx, js, cfg.DeadLetterStream)
+}
+
+func (s *Subscriber) ensureDeadLetterStream(ctx context.Context, js jetstream.JetStream, name string) error {
+	if name == "" {
+		return nil
+	}
+	return ensureStream(ctx, js, jetstream.StreamConfig{
+		Name:     name,
+		Subjects: []string{s.publisher.Subjects().DeadLetter},
+	})
+}
+
+// ensureStream tạo stream nếu chưa có; không sửa cấu hình của stream đã tồn tại.
+func ensureStream(ctx context.Context, js jetstream.JetStream, cfg jetstream.StreamConfig) error {
+	_, err := js.Stream(ctx, cfg.Name)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, jetstream.ErrStreamNotFound) {
+		return fmt.Errorf("failed to look up stream %s: %w", cfg.Name, err)
+	}
+	if _, err := js.CreateStream(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to create stream %s: %w", cfg.Name, err)
+	}
+	log.Printf("Created JetStream stream %s for subjects %v", cfg.Name, cfg.Subjects)
+	return nil
+}
@@ -0,0 +1,181 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// blockingProcessor giữ mỗi submission cho tới khi release bị đóng.
+type blockingProcessor struct {
+	started chan string
+	release chan struct{}
+}
+
+func (p *blockingProcessor) HandleSubmission(submission models.Submission, _ time.Time) {
+	p.started <- submission.ID
+	<-p.release
+}
+
+// TestConsumeJetStreamBoundsWorkers kiểm tra runner chỉ giữ tối đa workers message chưa ack, phần còn lại
+// nằm trong stream, và MaxAckPending của consumer lấy từ config thay vì từ số worker.
+func TestConsumeJetStreamBoundsWorkers(t *testing.T) {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	srv := natstest.RunServer(&opts)
+	defer srv.Shutdown()
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+
+	natsCfg := config.NATSConfig{}
+	jsCfg := config.JetStreamConfig{Stream: "SUBMISSIONS", Durable: "runner", AckWaitSec: 30, MaxDeliver: 5, MaxAckPending: 50}
+	processor := &blockingProcessor{started: make(chan string, 10), release: make(chan struct{})}
+	subscriber := NewSubscriber(nc, processor, NewPublisher(nc, natsCfg), natsCfg)
+	ctx := context.Background()
+	intake, err := subscriber.ConsumeJetStream(ctx, jsCfg, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		data, _ := json.Marshal(models.Submission{ID: fmt.Sprintf("s%d", i)})
+		if _, err := js.Publish(ctx, SubmissionCreatedSubject, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range 2 {
+		select {
+		case <-processor.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("workers did not start processing")
+		}
+	}
+	select {
+	case id := <-processor.started:
+		t.Fatalf("SubmissionID %s started while both workers were busy", id)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	consumer, err := js.Consumer(ctx, jsCfg.Stream, jsCfg.Durable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := consumer.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.MaxAckPending != 50 {
+		t.Errorf("consumer MaxAckPending = %d, want 50 from config", info.Config.MaxAckPending)
+	}
+	if info.NumAckPending != 2 || info.NumPending != 3 {
+		t.Errorf("NumAckPending = %d, NumPending = %d, want 2 and 3", info.NumAckPending, info.NumPending)
+	}
+	want := IntakeStats{Mode: IntakeJetStream, Workers: 2, Busy: 2, Idle: 0, Pending: 3, AckPending: 2}
+	if stats := intake.Stats(); stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+
+	close(processor.release)
+	for range 3 {
+		select {
+		case <-processor.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("remaining submissions were not processed")
+		}
+	}
+	intake.Stop()
+	info, err = consumer.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.NumAckPending != 0 || info.NumPending != 0 {
+		t.Errorf("after processing: NumAckPending = %d, NumPending = %d, want 0", info.NumAckPending, info.NumPending)
+	}
+}
+
+// TestEnsureDeadLetterStreamCoreMode kiểm tra dead-letter stream được tạo cả khi submission đi qua core NATS,
+// và submission nhận được giữ nguyên payload gốc trong Raw.
+func TestEnsureDeadLetterStreamCoreMode(t *testing.T) {
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	srv := natstest.RunServer(&opts)
+	defer srv.Shutdown()
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+
+	natsCfg := config.NATSConfig{}
+	processor := &recordingProcessor{received: make(chan models.Submission, 1)}
+	publisher := NewPublisher(nc, natsCfg)
+	subscriber := NewSubscriber(nc, processor, publisher, natsCfg)
+	ctx := context.Background()
+	if err := subscriber.EnsureDeadLetterStream(ctx, config.JetStreamConfig{DeadLetterStream: "SUBMISSIONS_DLQ"}); err != nil {
+		t.Fatal(err)
+	}
+	intake, err := subscriber.SubscribeToSubmissions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer intake.Stop()
+
+	raw := []byte(`{"id":"s1",  "languageId":"unknown"}`)
+	if err := nc.Publish(SubmissionCreatedSubject, raw); err != nil {
+		t.Fatal(err)
+	}
+	var sub models.Submission
+	select {
+	case sub = <-processor.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("submission was not delivered")
+	}
+	if string(sub.Raw) != string(raw) {
+		t.Errorf("Raw = %s, want the original payload %s", sub.Raw, raw)
+	}
+	if err := publisher.PublishDeadLetter(models.DeadLetter{SubmissionID: sub.ID, Stage: models.DeadLetterValidation, Payload: sub.Raw}); err != nil {
+		t.Fatal(err)
+	}
+	nc.Flush()
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := js.Stream(ctx, "SUBMISSIONS_DLQ")
+	if err != nil {
+		t.Fatalf("dead-letter stream was not created: %v", err)
+	}
+	msg, err := stream.GetLastMsgForSubject(ctx, publisher.Subjects().DeadLetter)
+	if err != nil {
+		t.Fatalf("dead letter was not stored: %v", err)
+	}
+	var dl models.DeadLetter
+	if err := json.Unmarshal(msg.Data, &dl); err != nil {
+		t.Fatal(err)
+	}
+	if string(dl.Payload) != string(raw) {
+		t.Errorf("stored payload = %s, want %s", dl.Payload, raw)
+	}
+}
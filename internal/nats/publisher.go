@@ -3,21 +3,37 @@ package nats
 import (
 	"encoding/json"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/Mirai3103/remote-compiler/internal/config"
 	"github.com/Mirai3103/remote-compiler/internal/models"
 	"github.com/nats-io/nats.go"
 )
 
+// Subject mặc định, dùng khi config không đặt (xem NewSubjects).
 const (
-	SubmissionResultSubject = "submission.executed"
+	SubmissionResultSubject  = "submission.executed"
+	SubmissionScoreSubject   = "submission.scored"
+	SubmissionSummarySubject = "submission.finished"
+	// SubmissionProgressSubjectPrefix + "." + submission ID là subject của các sự kiện tiến độ.
+	SubmissionProgressSubjectPrefix = "submission.progress"
+	DeadLetterSubject               = "submission.deadletter"
 )
 
 type Publisher struct {
-	nc *nats.Conn
+	nc       *nats.Conn
+	subjects Subjects
 }
 
-func NewPublisher(nc *nats.Conn) *Publisher {
-	return &Publisher{nc: nc}
+// NewPublisher tạo Publisher publish lên các subject trong cfg (đã áp dụng subjectPrefix).
+func NewPublisher(nc *nats.Conn, cfg config.NATSConfig) *Publisher {
+	return &Publisher{nc: nc, subjects: NewSubjects(cfg)}
+}
+
+// Subjects trả về các subject mà Publisher dùng.
+func (p *Publisher) Subjects() Subjects {
+	return p.subjects
 }
 
 func (p *Publisher) PublishSubmissionResult(result models.SubmissionResult) error {
@@ -27,10 +43,114 @@ func (p *Publisher) PublishSubmissionResult(result models.SubmissionResult) erro
 		return err
 	}
 
-	if err := p.nc.Publish(SubmissionResultSubject, data); err != nil {
+	if err := p.nc.Publish(p.subjects.SubmissionResult, data); err != nil {
 		log.Printf("Error publishing submission result to NATS: %v", err)
 		return err
 	}
-	log.Printf("Published result for SubmissionID: %s, TestCaseID: %s to NATS topic %s", result.SubmissionID, result.TestCaseID, SubmissionResultSubject)
+	log.Printf("Published result for SubmissionID: %s, TestCaseID: %s to NATS topic %s", result.SubmissionID, result.TestCaseID, p.subjects.SubmissionResult)
+	return nil
+}
+
+// PublishSubmissionScore publish điểm của submission có subtask, sau khi mọi test case đã có kết quả.
+func (p *Publisher) PublishSubmissionScore(score models.SubmissionScore) error {
+	data, err := json.Marshal(score)
+	if err != nil {
+		log.Printf("Error marshalling submission score: %v", err)
+		return err
+	}
+
+	if err := p.nc.Publish(p.subjects.SubmissionScore, data); err != nil {
+		log.Printf("Error publishing submission score to NATS: %v", err)
+		return err
+	}
+	log.Printf("Published score %g/%g for SubmissionID: %s to NATS topic %s", score.Score, score.MaxScore, score.SubmissionID, p.subjects.SubmissionScore)
+	return nil
+}
+
+// PublishSubmissionSummary publish message tổng kết của submission.
+func (p *Publisher) PublishSubmissionSummary(summary models.SubmissionSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		log.Printf("Error marshalling submission summary: %v", err)
+		return err
+	}
+
+	if err := p.nc.Publish(p.subjects.SubmissionSummary, data); err != nil {
+		log.Printf("Error publishing submission summary to NATS: %v", err)
+		return err
+	}
+	log.Printf("Published summary for SubmissionID: %s (verdict %s, %d/%d passed) to NATS topic %s",
+		summary.SubmissionID, summary.Verdict, summary.Passed, summary.Total, p.subjects.SubmissionSummary)
+	return nil
+}
+
+// PublishSubmissionProgress publish một sự kiện tiến độ lên "<submissionProgressSubjectPrefix>.<submissionId>".
+// Lỗi chỉ được log: sự kiện tiến độ không ảnh hưởng tới việc chấm.
+func (p *Publisher) PublishSubmissionProgress(progress models.SubmissionProgress) {
+	if progress.Timestamp == 0 {
+		progress.Timestamp = time.Now().UnixMilli()
+	}
+	data, err := json.Marshal(progress)
+	if err != nil {
+		log.Printf("Error marshalling submission progress: %v", err)
+		return
+	}
+	subject := p.subjects.SubmissionProgressPrefix + "." + subjectToken(progress.SubmissionID)
+	if err := p.nc.Publish(subject, data); err != nil {
+		log.Printf("Error publishing submission progress to NATS: %v", err)
+	}
+}
+
+// subjectToken thay các ký tự có nghĩa đặc biệt trong NATS subject (".", "*", ">", khoảng trắng) bằng "_",
+// để submission ID luôn là đúng một token.
+func subjectToken(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// PublishDeadLetter publish một message không xử lý được lên dead-letter subject. Nếu dead-letter
+// stream tồn tại, message được lưu lại để xem và gửi lại bằng cmd/deadletter.
+func (p *Publisher) PublishDeadLetter(dl models.DeadLetter) error {
+	if dl.FailedAt == 0 {
+		dl.FailedAt = time.Now().UnixMilli()
+	}
+	data, err := json.Marshal(dl)
+	if err != nil {
+		log.Printf("Error marshalling dead letter: %v", err)
+		return err
+	}
+
+	if err := p.nc.Publish(p.subjects.DeadLetter, data); err != nil {
+		log.Printf("Error publishing dead letter to NATS: %v", err)
+		return err
+	}
+	log.Printf("Published dead letter (stage %s, SubmissionID %q) to NATS topic %s: %s", dl.Stage, dl.SubmissionID, p.subjects.DeadLetter, dl.Reason)
+	return nil
+}
+
+// PublishSubmissionCancel gửi yêu cầu hủy submission tới mọi runner (kể cả runner này).
+func (p *Publisher) PublishSubmissionCancel(cancel models.SubmissionCancel) error {
+	if cancel.RequestedAt == 0 {
+		cancel.RequestedAt = time.Now().UnixMilli()
+	}
+	data, err := json.Marshal(cancel)
+	if err != nil {
+		log.Printf("Error marshalling cancel request: %v", err)
+		return err
+	}
+
+	if err := p.nc.Publish(p.subjects.SubmissionCancel, data); err != nil {
+		log.Printf("Error publishing cancel request to NATS: %v", err)
+		return err
+	}
+	log.Printf("Published cancel request for SubmissionID: %s to NATS topic %s", cancel.SubmissionID, p.subjects.SubmissionCancel)
 	return nil
 }
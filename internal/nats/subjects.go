@@ -0,0 +1,48 @@
+package nats
+
+import (
+	"github.com/Mirai3103/remote-compiler/internal/config"
+)
+
+// Subjects là các subject và queue group runner dùng, đã áp dụng nats.subjectPrefix.
+type Subjects struct {
+	SubmissionCreated        string
+	SubmissionCancel         string
+	SubmissionRun            string // Request-reply, xem Subscriber.SubscribeToRunRequests
+	SubmissionResult         string
+	SubmissionScore          string
+	SubmissionSummary        string
+	SubmissionProgressPrefix string // Subject của sự kiện tiến độ là SubmissionProgressPrefix + "." + submission ID
+	DeadLetter               string
+	QueueGroup               string
+}
+
+// NewSubjects đọc subject từ config; giá trị rỗng dùng hằng số mặc định của package. Nếu có
+// SubjectPrefix (ví dụ "staging"), mọi subject được thêm tiền tố "staging.", để nhiều môi trường
+// dùng chung một NATS cluster. Queue group không có tiền tố vì chỉ có nghĩa trong phạm vi một subject.
+func NewSubjects(cfg config.NATSConfig) Subjects {
+	withPrefix := func(subject, fallback string) string {
+		if subject == "" {
+			subject = fallback
+		}
+		if cfg.SubjectPrefix == "" {
+			return subject
+		}
+		return cfg.SubjectPrefix + "." + subject
+	}
+	queueGroup := cfg.QueueGroup
+	if queueGroup == "" {
+		queueGroup = QueueGroup
+	}
+	return Subjects{
+		SubmissionCreated:        withPrefix(cfg.SubmissionCreatedSubj, SubmissionCreatedSubject),
+		SubmissionCancel:         withPrefix(cfg.SubmissionCancelSubj, SubmissionCancelSubject),
+		SubmissionRun:            withPrefix(cfg.SubmissionRunSubj, SubmissionRunSubject),
+		SubmissionResult:         withPrefix(cfg.SubmissionResultSubj, SubmissionResultSubject),
+		SubmissionScore:          withPrefix(cfg.SubmissionScoreSubj, SubmissionScoreSubject),
+		SubmissionSummary:        withPrefix(cfg.SubmissionSummarySubj, SubmissionSummarySubject),
+		SubmissionProgressPrefix: withPrefix(cfg.SubmissionProgressSubjPrefix, SubmissionProgressSubjectPrefix),
+		DeadLetter:               withPrefix(cfg.DeadLetterSubj, DeadLetterSubject),
+		QueueGroup:               queueGroup,
+	}
+}
@@ -0,0 +1,188 @@
+package nats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// recordingProcessor ghi lại các submission Subscriber giao cho nó.
+type recordingProcessor struct {
+	received chan models.Submission
+}
+
+func (p *recordingProcessor) HandleSubmission(submission models.Submission, _ time.Time) {
+	p.received <- submission
+}
+
+func startServer(t *testing.T) (*server.Server, *nats.Conn) {
+	t.Helper()
+	opts := natstest.DefaultTestOptions
+	opts.Port = -1
+	srv := natstest.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	return srv, nc
+}
+
+func TestNewSubjects(t *testing.T) {
+	defaults := NewSubjects(config.NATSConfig{})
+	if defaults.SubmissionCreated != SubmissionCreatedSubject || defaults.SubmissionResult != SubmissionResultSubject ||
+		defaults.DeadLetter != DeadLetterSubject || defaults.QueueGroup != QueueGroup {
+		t.Errorf("NewSubjects with empty config = %+v, want package defaults", defaults)
+	}
+
+	subjects := NewSubjects(config.NATSConfig{
+		SubmissionCreatedSubj: "jobs.new",
+		QueueGroup:            "judges",
+		SubjectPrefix:         "staging",
+	})
+	want := map[string]string{
+		"SubmissionCreated": "staging.jobs.new",
+		"SubmissionResult":  "staging." + SubmissionResultSubject,
+		"SubmissionCancel":  "staging." + SubmissionCancelSubject,
+		"DeadLetter":        "staging." + DeadLetterSubject,
+		"QueueGroup":        "judges", // Queue group không có tiền tố
+	}
+	got := map[string]string{
+		"SubmissionCreated": subjects.SubmissionCreated,
+		"SubmissionResult":  subjects.SubmissionResult,
+		"SubmissionCancel":  subjects.SubmissionCancel,
+		"DeadLetter":        subjects.DeadLetter,
+		"QueueGroup":        subjects.QueueGroup,
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %q, want %q", name, got[name], w)
+		}
+	}
+}
+
+// TestConfiguredSubjects chạy Subscriber và Publisher trên NATS server nhúng với subject, queue group và
+// tiền tố tùy chỉnh, rồi kiểm tra mọi message chỉ đi qua các subject đã có tiền tố.
+func TestConfiguredSubjects(t *testing.T) {
+	srv, nc := startServer(t)
+	cfg := config.NATSConfig{
+		SubmissionCreatedSubj: "jobs.new",
+		SubmissionResultSubj:  "jobs.result",
+		QueueGroup:            "judges",
+		SubjectPrefix:         "staging",
+	}
+
+	// Ghi lại subject của mọi message đi qua server.
+	seen := make(chan string, 100)
+	spy, err := nc.Subscribe(">", func(msg *nats.Msg) { seen <- msg.Subject })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spy.Unsubscribe()
+
+	processor := &recordingProcessor{received: make(chan models.Submission, 10)}
+	publisher := NewPublisher(nc, cfg)
+	intake, err := NewSubscriber(nc, processor, publisher, cfg).SubscribeToSubmissions(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer intake.Stop()
+
+	if err := nc.Flush(); err != nil { // Để server đã nhận các subscription
+		t.Fatal(err)
+	}
+
+	// Subscription của intake phải dùng subject có tiền tố và queue group đã cấu hình.
+	subsz, err := srv.Subsz(&server.SubszOptions{Subscriptions: true, Test: "staging.jobs.new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	workers := 0
+	for _, sub := range subsz.Subs {
+		if sub.Subject == ">" { // spy
+			continue
+		}
+		workers++
+		if sub.Subject != "staging.jobs.new" || sub.Queue != "judges" {
+			t.Errorf("intake subscribed to %q with queue group %q, want %q with %q", sub.Subject, sub.Queue, "staging.jobs.new", "judges")
+		}
+	}
+	if workers != 2 {
+		t.Fatalf("got %d intake subscriptions, want 2 (one per worker)", workers)
+	}
+
+	// Submission gửi tới subject mặc định hoặc subject chưa có tiền tố không được nhận.
+	data, _ := json.Marshal(models.Submission{ID: "s1"})
+	for _, subject := range []string{SubmissionCreatedSubject, "jobs.new", "staging." + SubmissionCreatedSubject} {
+		if err := nc.Publish(subject, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := nc.Publish("staging.jobs.new", data); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-processor.received:
+		if got.ID != "s1" {
+			t.Errorf("received SubmissionID %q, want s1", got.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("submission published on staging.jobs.new was not received")
+	}
+	if err := nc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-processor.received:
+		t.Fatalf("received unexpected submission %q from an unconfigured subject", got.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Publisher chỉ publish lên subject có tiền tố.
+	drain(seen)
+	if err := publisher.PublishSubmissionResult(models.SubmissionResult{SubmissionID: "s1", TestCaseID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.PublishSubmissionSummary(models.SubmissionSummary{SubmissionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	publisher.PublishSubmissionProgress(models.SubmissionProgress{SubmissionID: "s1", Stage: models.StageRunning})
+	if err := nc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"staging.jobs.result", "staging." + SubmissionSummarySubject, "staging." + SubmissionProgressSubjectPrefix + ".s1"}
+	for _, w := range want {
+		select {
+		case got := <-seen:
+			if got != w {
+				t.Errorf("message published on %q, want %q", got, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no message published on %q", w)
+		}
+	}
+	select {
+	case got := <-seen:
+		t.Errorf("unexpected message on %q", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// drain bỏ các subject đã ghi lại.
+func drain(seen chan string) {
+	for {
+		select {
+		case <-seen:
+		case <-time.After(100 * time.Millisecond):
+			return
+		}
+	}
+}
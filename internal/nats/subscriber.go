@@ -1,57 +1,332 @@
 package nats
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Mirai3103/remote-compiler/internal/config"
 	"github.com/Mirai3103/remote-compiler/internal/models"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	// KHÔNG import "runner-service/internal/worker" ở đây nữa
 )
 
+// Subject và queue group mặc định, dùng khi config không đặt (xem NewSubjects).
 const (
 	SubmissionCreatedSubject = "submission.created"
+	SubmissionCancelSubject  = "submission.cancel"
+	SubmissionRunSubject     = "submission.run"
 	QueueGroup               = "runner-service-group"
 )
 
 // SubmissionProcessor defines the interface for handling submissions.
 // Any type that implements HandleSubmission can be used by the NATS subscriber.
+// queuedAt là thời điểm submission được đưa vào hàng đợi: lúc runner nhận message với core NATS,
+// lúc message được lưu vào stream với JetStream.
 type SubmissionProcessor interface {
-	HandleSubmission(submission models.Submission)
+	HandleSubmission(submission models.Submission, queuedAt time.Time)
+}
+
+// SubmissionCanceller hủy submission theo yêu cầu nhận từ subject submission.cancel.
+type SubmissionCanceller interface {
+	CancelSubmission(cancel models.SubmissionCancel)
+}
+
+// RunRequestHandler chấm submission của một request chạy code đồng bộ và trả về mọi kết quả.
+// Nếu runner không có slot trống thì trả về ngay câu trả lời có Busy = true thay vì chờ slot.
+type RunRequestHandler interface {
+	TryHandleRunRequest(ctx context.Context, req models.RunSubmissionRequest) models.RunSubmissionResponse
 }
 
 type Subscriber struct {
 	nc                *nats.Conn
 	submissionHandler SubmissionProcessor // Thay đổi ở đây: dùng interface
+	publisher         *Publisher          // Để chuyển message không xử lý được vào dead-letter
+	subjects          Subjects
 }
 
 // NewSubscriber bây giờ nhận một SubmissionProcessor
-func NewSubscriber(nc *nats.Conn, handler SubmissionProcessor) *Subscriber {
+func NewSubscriber(nc *nats.Conn, handler SubmissionProcessor, publisher *Publisher, cfg config.NATSConfig) *Subscriber {
 	return &Subscriber{
 		nc:                nc,
 		submissionHandler: handler, // Gán interface
+		publisher:         publisher,
+		subjects:          NewSubjects(cfg),
 	}
 }
 
-func (s *Subscriber) SubscribeToSubmissions() (*nats.Subscription, error) {
-	subscription, err := s.nc.QueueSubscribe(SubmissionCreatedSubject, QueueGroup, func(msg *nats.Msg) {
-		log.Printf("Received a message on subject: %s, queue: %s", msg.Subject, msg.Sub.Queue)
-		var sub models.Submission
-		err := json.Unmarshal(msg.Data, &sub)
+const (
+	resubscribeDelay = time.Second     // Thời gian chờ trước khi worker thử subscribe lại sau lỗi
+	statsTimeout     = 2 * time.Second // Thời gian chờ server trả về thông tin consumer cho Stats
+)
+
+// Chế độ nhận submission của Intake.
+const (
+	IntakeCore      = "core"
+	IntakeJetStream = "jetstream"
+)
+
+// Intake là nhóm worker nhận submission, từ queue group bằng core NATS hoặc từ consumer của JetStream.
+type Intake struct {
+	mode       string
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	workers    int          // 0 = không giới hạn
+	busy       atomic.Int64 // Số submission đang được xử lý
+	unbound    *nats.Subscription
+	consumer   jetstream.Consumer       // Chỉ với JetStream
+	consumeCtx jetstream.ConsumeContext // JetStream không giới hạn worker
+}
+
+// IntakeStats là trạng thái hiện tại của Intake.
+type IntakeStats struct {
+	Mode    string `json:"mode"`    // "core" hoặc "jetstream"
+	Workers int    `json:"workers"` // 0 = không giới hạn
+	Busy    int64  `json:"busy"`    // Submission đang xử lý
+	Idle    int64  `json:"idle"`    // Worker đang chờ submission (mỗi worker nhận tối đa một message)
+	// Chỉ với JetStream: số message còn trong stream chưa giao cho runner nào (độ sâu hàng đợi chung)
+	// và số message đã giao nhưng chưa ack, tính trên mọi runner dùng chung consumer.
+	Pending    uint64 `json:"pending,omitempty"`
+	AckPending int    `json:"ackPending,omitempty"`
+}
+
+// Stats trả về số worker đang bận và đang rảnh; với JetStream còn có độ sâu hàng đợi của consumer.
+func (in *Intake) Stats() IntakeStats {
+	stats := IntakeStats{Mode: in.mode, Workers: in.workers, Busy: in.busy.Load()}
+	if in.workers > 0 {
+		stats.Idle = max(int64(in.workers)-stats.Busy, 0)
+	}
+	if in.consumer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+		defer cancel()
+		info, err := in.consumer.Info(ctx)
 		if err != nil {
-			log.Printf("Error unmarshalling submission data: %v. Message data: %s", err, string(msg.Data))
-			return
+			log.Printf("Error reading JetStream consumer info: %v", err)
+			return stats
 		}
+		stats.Pending = info.NumPending
+		stats.AckPending = info.NumAckPending
+	}
+	return stats
+}
 
-		// Gọi method của interface
-		go s.submissionHandler.HandleSubmission(sub)
-	})
+// Stop ngừng nhận submission mới và chờ các submission đang xử lý chạy xong.
+func (in *Intake) Stop() {
+	if in.unbound != nil {
+		if err := in.unbound.Unsubscribe(); err != nil {
+			log.Printf("Error unsubscribing: %v", err)
+		}
+	}
+	if in.consumeCtx != nil {
+		in.consumeCtx.Stop()
+	}
+	in.cancel()
+	in.wg.Wait()
+}
+
+// SubscribeToSubmissions nhận submission từ queue group với tối đa workers submission cùng lúc.
+// Mỗi worker rảnh giữ một queue subscription chỉ nhận một message (AutoUnsubscribe(1)) và chỉ
+// subscribe lại khi đã xử lý xong, nên khi mọi worker đều bận, runner này không còn subscription nào
+// và NATS giao submission mới cho các runner khác trong queue group thay vì dồn vào bộ nhớ ở đây.
+// workers <= 0 giữ cách cũ: nhận mọi message và xử lý mỗi message trong một goroutine riêng.
+func (s *Subscriber) SubscribeToSubmissions(workers int) (*Intake, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := &Intake{mode: IntakeCore, cancel: cancel, workers: max(workers, 0)}
+	if workers <= 0 {
+		subscription, err := s.nc.QueueSubscribe(s.subjects.SubmissionCreated, s.subjects.QueueGroup, func(msg *nats.Msg) {
+			log.Printf("Received a message on subject: %s, queue: %s", msg.Subject, msg.Sub.Queue)
+			if sub, ok := s.decode(msg.Data, msg.Subject); ok {
+				queuedAt := time.Now()
+				in.wg.Add(1)
+				go func() {
+					defer in.wg.Done()
+					s.handle(in, sub, queuedAt)
+				}()
+			}
+		})
+		if err != nil {
+			cancel()
+			log.Printf("Error subscribing to NATS subject %s: %v", s.subjects.SubmissionCreated, err)
+			return nil, err
+		}
+		in.unbound = subscription
+		log.Printf("Subscribed to NATS subject: %s, queue group: %s (unbounded intake)", s.subjects.SubmissionCreated, s.subjects.QueueGroup)
+		return in, nil
+	}
 
+	// Subscribe cho mọi worker trước, để lỗi cấu hình (subject sai...) được trả về ngay.
+	subscriptions := make([]*nats.Subscription, 0, workers)
+	for range workers {
+		sub, err := s.subscribeOne()
+		if err != nil {
+			for _, sub := range subscriptions {
+				sub.Unsubscribe()
+			}
+			cancel()
+			log.Printf("Error subscribing to NATS subject %s: %v", s.subjects.SubmissionCreated, err)
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	for _, sub := range subscriptions {
+		in.wg.Add(1)
+		go s.intakeWorker(ctx, in, sub)
+	}
+
+	log.Printf("Subscribed to NATS subject: %s, queue group: %s with %d workers", s.subjects.SubmissionCreated, s.subjects.QueueGroup, workers)
+	return in, nil
+}
+
+// subscribeOne tạo một queue subscription chỉ nhận đúng một message.
+func (s *Subscriber) subscribeOne() (*nats.Subscription, error) {
+	sub, err := s.nc.QueueSubscribeSync(s.subjects.SubmissionCreated, s.subjects.QueueGroup)
 	if err != nil {
-		log.Printf("Error subscribing to NATS subject %s: %v", SubmissionCreatedSubject, err)
 		return nil, err
 	}
+	if err := sub.AutoUnsubscribe(1); err != nil {
+		sub.Unsubscribe()
+		return nil, err
+	}
+	return sub, nil
+}
 
-	log.Printf("Subscribed to NATS subject: %s, queue group: %s", SubmissionCreatedSubject, QueueGroup)
+// intakeWorker nhận một submission, xử lý xong rồi mới subscribe lại.
+func (s *Subscriber) intakeWorker(ctx context.Context, in *Intake, sub *nats.Subscription) {
+	defer in.wg.Done()
+	for {
+		msg, err := sub.NextMsgWithContext(ctx)
+		if err != nil {
+			sub.Unsubscribe()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error receiving from NATS subject %s: %v", s.subjects.SubmissionCreated, err)
+		} else {
+			log.Printf("Received a message on subject: %s, queue: %s", msg.Subject, s.subjects.QueueGroup)
+			if submission, ok := s.decode(msg.Data, msg.Subject); ok {
+				s.handle(in, submission, time.Now())
+			}
+		}
+
+		// Slot đã rảnh: subscribe lại để nhận submission tiếp theo.
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			if sub, err = s.subscribeOne(); err == nil {
+				break
+			}
+			log.Printf("Error resubscribing to NATS subject %s: %v", s.subjects.SubmissionCreated, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(resubscribeDelay):
+			}
+		}
+	}
+}
+
+// handle xử lý một submission và cập nhật số worker đang bận.
+func (s *Subscriber) handle(in *Intake, submission models.Submission, queuedAt time.Time) {
+	busy := in.busy.Add(1)
+	defer in.busy.Add(-1)
+	if in.workers > 0 {
+		log.Printf("Intake: SubmissionID %s accepted, %d/%d workers busy", submission.ID, busy, in.workers)
+	}
+	// Gọi method của interface
+	s.submissionHandler.HandleSubmission(submission, queuedAt)
+}
+
+// decode đọc submission từ payload; payload không hợp lệ được chuyển vào dead-letter.
+func (s *Subscriber) decode(data []byte, subject string) (models.Submission, bool) {
+	var sub models.Submission
+	if err := json.Unmarshal(data, &sub); err != nil {
+		log.Printf("Error unmarshalling submission data: %v. Message data: %s", err, string(data))
+		s.publisher.PublishDeadLetter(models.DeadLetter{
+			Stage:   models.DeadLetterDecode,
+			Reason:  err.Error(),
+			Subject: subject,
+			Payload: data,
+		})
+		return sub, false
+	}
+	sub.Raw = data
+	return sub, true
+}
+
+// SubscribeToCancellations nhận yêu cầu hủy submission. Khác với submission, mọi runner đều phải nhận
+// yêu cầu hủy (không biết runner nào đang giữ submission), nên không dùng queue group.
+func (s *Subscriber) SubscribeToCancellations(canceller SubmissionCanceller) (*nats.Subscription, error) {
+	subscription, err := s.nc.Subscribe(s.subjects.SubmissionCancel, func(msg *nats.Msg) {
+		var cancel models.SubmissionCancel
+		if err := json.Unmarshal(msg.Data, &cancel); err != nil || cancel.SubmissionID == "" {
+			log.Printf("Ignoring invalid cancel request on subject %s: %v. Message data: %s", msg.Subject, err, string(msg.Data))
+			return
+		}
+		if cancel.RequestedAt == 0 {
+			cancel.RequestedAt = time.Now().UnixMilli()
+		}
+		canceller.CancelSubmission(cancel)
+	})
+	if err != nil {
+		log.Printf("Error subscribing to NATS subject %s: %v", s.subjects.SubmissionCancel, err)
+		return nil, err
+	}
+	log.Printf("Subscribed to NATS subject: %s", s.subjects.SubmissionCancel)
 	return subscription, nil
 }
+
+// SubscribeToRunRequests nhận request chạy code trên subject submission.run và trả lời bằng
+// msg.Respond với toàn bộ kết quả. Request dùng chung queue group với submission nên chỉ một runner trả lời.
+// Runner không xếp hàng request: khi mọi slot đang bận, request được trả lời ngay với busy = true để người
+// gửi gửi lại (và có thể tới runner đang rảnh), thay vì chờ slot ở runner này trong khi runner khác rảnh.
+func (s *Subscriber) SubscribeToRunRequests(handler RunRequestHandler) (*nats.Subscription, error) {
+	subscription, err := s.nc.QueueSubscribe(s.subjects.SubmissionRun, s.subjects.QueueGroup, func(msg *nats.Msg) {
+		if msg.Reply == "" {
+			log.Printf("Ignoring run request without reply subject on %s", msg.Subject)
+			return
+		}
+		var req models.RunSubmissionRequest
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			log.Printf("Error unmarshalling run request: %v. Message data: %s", err, string(msg.Data))
+			s.respond(msg, models.RunSubmissionResponse{Results: []models.SubmissionResult{}, Error: err.Error()})
+			return
+		}
+		log.Printf("Received run request for SubmissionID %s on subject: %s", req.ID, msg.Subject)
+		// Chấm trong goroutine riêng để callback trả về ngay; handler không chờ slot.
+		go func() { s.respond(msg, handler.TryHandleRunRequest(context.Background(), req)) }()
+	})
+	if err != nil {
+		log.Printf("Error subscribing to NATS subject %s: %v", s.subjects.SubmissionRun, err)
+		return nil, err
+	}
+	log.Printf("Subscribed to NATS subject: %s, queue group: %s", s.subjects.SubmissionRun, s.subjects.QueueGroup)
+	return subscription, nil
+}
+
+// respond gửi câu trả lời của request chạy code. Nếu reply vượt quá max payload của server,
+// output của các test case bị bỏ đi để người gửi vẫn nhận được status.
+func (s *Subscriber) respond(msg *nats.Msg, resp models.RunSubmissionResponse) {
+	data, err := json.Marshal(resp)
+	if err == nil && int64(len(data)) > s.nc.MaxPayload() {
+		for i := range resp.Results {
+			resp.Results[i].Output = ""
+			resp.Results[i].Transcript = ""
+		}
+		resp.Error = "response exceeds the NATS max payload; test case outputs were omitted"
+		data, err = json.Marshal(resp)
+	}
+	if err != nil {
+		log.Printf("Error marshalling run response for SubmissionID %s: %v", resp.SubmissionID, err)
+		return
+	}
+	if err := msg.Respond(data); err != nil {
+		log.Printf("Error responding to run request for SubmissionID %s: %v", resp.SubmissionID, err)
+		return
+	}
+	log.Printf("Responded to run request for SubmissionID %s (%d results)", resp.SubmissionID, len(resp.Results))
+}
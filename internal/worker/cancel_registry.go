@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Mirai3103/remote-compiler/internal/core"
+)
+
+// cancelTombstoneTTL là thời gian runner ghi nhớ yêu cầu hủy của submission mà nó chưa nhận được.
+// Yêu cầu hủy được gửi tới mọi runner, nên submission còn nằm trong hàng đợi (ví dụ JetStream)
+// sẽ bị bỏ qua bởi runner nhận nó sau đó, miễn là trong khoảng thời gian này.
+const cancelTombstoneTTL = 10 * time.Minute
+
+// inflight là một submission runner đã nhận, đang chờ slot hoặc đang chạy.
+type inflight struct {
+	cancel   context.CancelCauseFunc
+	queuedAt time.Time
+}
+
+// cancelRegistry giữ context của các submission đang xử lý để có thể hủy chúng theo submission ID.
+// Submission ID lặp lại khi chấm lại, nên yêu cầu hủy chỉ áp dụng cho submission được đưa vào hàng đợi
+// trước thời điểm gửi yêu cầu.
+type cancelRegistry struct {
+	mu        sync.Mutex
+	running   map[string][]*inflight // Một ID có thể được nhận nhiều lần (ví dụ chấm lại)
+	cancelled map[string]cancelTombstone
+}
+
+type cancelTombstone struct {
+	cause       error
+	requestedAt time.Time
+	expiresAt   time.Time
+}
+
+func newCancelRegistry() *cancelRegistry {
+	return &cancelRegistry{
+		running:   make(map[string][]*inflight),
+		cancelled: make(map[string]cancelTombstone),
+	}
+}
+
+// register tạo context (con của parent) cho một submission vừa nhận, được đưa vào hàng đợi lúc queuedAt.
+// Nếu submission đã bị hủy trước khi tới runner, context trả về đã bị hủy sẵn. release phải được gọi khi
+// submission xử lý xong.
+func (c *cancelRegistry) register(parent context.Context, submissionID string, queuedAt time.Time) (ctx context.Context, release func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	entry := &inflight{cancel: cancel, queuedAt: queuedAt}
+
+	c.mu.Lock()
+	c.pruneLocked(time.Now())
+	// Tombstone được giữ tới khi hết hạn để cả các lần giao lại của cùng message cũng bị bỏ qua.
+	if tombstone, ok := c.cancelled[submissionID]; ok && !queuedAt.After(tombstone.requestedAt) {
+		cancel(tombstone.cause)
+	}
+	c.running[submissionID] = append(c.running[submissionID], entry)
+	c.mu.Unlock()
+
+	return ctx, func() {
+		c.mu.Lock()
+		entries := c.running[submissionID]
+		for i, e := range entries {
+			if e == entry {
+				entries = append(entries[:i], entries[i+1:]...)
+				break
+			}
+		}
+		if len(entries) == 0 {
+			delete(c.running, submissionID)
+		} else {
+			c.running[submissionID] = entries
+		}
+		c.mu.Unlock()
+		cancel(context.Canceled)
+	}
+}
+
+// cancel hủy mọi lần xử lý đang diễn ra của submission được đưa vào hàng đợi trước requestedAt. Nếu runner
+// không giữ lần nào như vậy, yêu cầu được ghi nhớ trong cancelTombstoneTTL. Trả về true nếu có submission bị hủy.
+func (c *cancelRegistry) cancel(submissionID, reason string, requestedAt time.Time) bool {
+	cause := core.ErrCancelled
+	if reason != "" {
+		cause = fmt.Errorf("%w: %s", core.ErrCancelled, reason)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.pruneLocked(now)
+	cancelled := false
+	for _, e := range c.running[submissionID] {
+		if !e.queuedAt.After(requestedAt) {
+			e.cancel(cause)
+			cancelled = true
+		}
+	}
+	if !cancelled {
+		if tombstone, ok := c.cancelled[submissionID]; !ok || requestedAt.After(tombstone.requestedAt) {
+			c.cancelled[submissionID] = cancelTombstone{cause: cause, requestedAt: requestedAt, expiresAt: now.Add(cancelTombstoneTTL)}
+		}
+	}
+	return cancelled
+}
+
+// pruneLocked xóa các yêu cầu hủy đã hết hạn. Gọi khi đang giữ c.mu.
+func (c *cancelRegistry) pruneLocked(now time.Time) {
+	for id, tombstone := range c.cancelled {
+		if now.After(tombstone.expiresAt) {
+			delete(c.cancelled, id)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Mirai3103/remote-compiler/internal/core"
+)
+
+func TestCancelRegistry(t *testing.T) {
+	t0 := time.Now()
+
+	t.Run("cancels running submission", func(t *testing.T) {
+		c := newCancelRegistry()
+		ctx, release := c.register(context.Background(), "s1", t0)
+		defer release()
+		if !c.cancel("s1", "stop", t0.Add(time.Second)) {
+			t.Fatal("cancel returned false for a running submission")
+		}
+		if !errors.Is(context.Cause(ctx), core.ErrCancelled) {
+			t.Errorf("cause = %v, want ErrCancelled", context.Cause(ctx))
+		}
+	})
+
+	t.Run("drops submission queued before the request", func(t *testing.T) {
+		c := newCancelRegistry()
+		if c.cancel("s1", "", t0) {
+			t.Fatal("cancel returned true without a running submission")
+		}
+		for range 2 { // Lần giao lại của cùng message cũng bị bỏ qua
+			ctx, release := c.register(context.Background(), "s1", t0.Add(-time.Second))
+			if ctx.Err() == nil {
+				t.Error("submission queued before the cancel request was not cancelled")
+			}
+			release()
+		}
+	})
+
+	t.Run("keeps rejudge queued after the request", func(t *testing.T) {
+		c := newCancelRegistry()
+		c.cancel("s1", "", t0)
+		ctx, release := c.register(context.Background(), "s1", t0.Add(time.Second))
+		defer release()
+		if ctx.Err() != nil {
+			t.Errorf("rejudge queued after the cancel request was cancelled: %v", context.Cause(ctx))
+		}
+	})
+
+	t.Run("does not cancel running rejudge", func(t *testing.T) {
+		c := newCancelRegistry()
+		ctx, release := c.register(context.Background(), "s1", t0.Add(time.Second))
+		defer release()
+		if c.cancel("s1", "", t0) {
+			t.Error("cancel returned true for a submission queued after the request")
+		}
+		if ctx.Err() != nil {
+			t.Errorf("running rejudge was cancelled: %v", context.Cause(ctx))
+		}
+	})
+
+	t.Run("tombstone expires", func(t *testing.T) {
+		c := newCancelRegistry()
+		c.cancel("s1", "", t0)
+		tombstone := c.cancelled["s1"]
+		tombstone.expiresAt = time.Now().Add(-time.Second)
+		c.cancelled["s1"] = tombstone
+		ctx, release := c.register(context.Background(), "s1", t0.Add(-time.Second))
+		defer release()
+		if ctx.Err() != nil {
+			t.Error("expired cancel request still cancelled the submission")
+		}
+	})
+}
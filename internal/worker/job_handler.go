@@ -2,18 +2,45 @@ package worker
 
 import (
 	"context"
+	"fmt"
 	"github.com/Mirai3103/remote-compiler/internal/config"
 	"github.com/Mirai3103/remote-compiler/internal/core"
 	"github.com/Mirai3103/remote-compiler/internal/models" // Điều chỉnh import path nếu cần
 	natsClient "github.com/Mirai3103/remote-compiler/internal/nats"
 	"log"
+	"sync/atomic"
 	"time"
 )
 
+// Thời hạn của request chạy code (submission.run) khi request không đặt timeoutInMs, và thời hạn tối đa.
+const (
+	defaultRunTimeout = 30 * time.Second
+	maxRunTimeout     = 5 * time.Minute // Bằng timeout của submission chấm bất đồng bộ
+)
+
 type JobHandler struct {
 	natsPublisher *natsClient.Publisher
 	runner        *core.Runner
 	jobSemaphore  chan struct{}
+	cancels       *cancelRegistry // Context của các submission đang chờ slot hoặc đang chạy
+	running       atomic.Int64
+	waiting       atomic.Int64 // Submission đang chờ slot
+}
+
+// JobStats là trạng thái hiện tại của JobHandler, tính cả submission từ NATS, HTTP và gRPC.
+type JobStats struct {
+	MaxConcurrentJobs int   `json:"maxConcurrentJobs"` // 0 = không giới hạn
+	Running           int64 `json:"running"`
+	Waiting           int64 `json:"waiting"` // Đang chờ slot
+}
+
+// Stats trả về số submission đang chạy và đang chờ slot.
+func (h *JobHandler) Stats() JobStats {
+	return JobStats{
+		MaxConcurrentJobs: cap(h.jobSemaphore),
+		Running:           h.running.Load(),
+		Waiting:           h.waiting.Load(),
+	}
 }
 
 func NewJobHandler(publisher *natsClient.Publisher, runner *core.Runner, runnerCfg *config.RunnerConfig) *JobHandler {
@@ -30,12 +57,40 @@ func NewJobHandler(publisher *natsClient.Publisher, runner *core.Runner, runnerC
 		natsPublisher: publisher,
 		runner:        runner,
 		jobSemaphore:  sem,
+		cancels:       newCancelRegistry(),
 	}
 }
 
-// HandleSubmission processes a single submission.
+// HandleSubmission processes a single submission, queued at queuedAt.
 // This method signature matches the SubmissionProcessor interface in the nats package.
-func (h *JobHandler) HandleSubmission(submission models.Submission) {
+func (h *JobHandler) HandleSubmission(submission models.Submission, queuedAt time.Time) {
+	h.handle(context.Background(), submission, queuedAt, func(ctx context.Context) {
+		h.runner.ProcessSubmission(ctx, submission)
+	})
+}
+
+// HandleSubmissionWith xử lý submission giống HandleSubmission (cùng giới hạn maxConcurrentJobs, có thể
+// bị hủy), nhưng kết quả được gửi cho reporter thay vì publish lên NATS. Dùng cho HTTP và gRPC API;
+// submission cũng dừng khi ctx bị hủy (ví dụ client gRPC ngắt kết nối).
+func (h *JobHandler) HandleSubmissionWith(ctx context.Context, submission models.Submission, reporter core.Reporter) {
+	h.handle(ctx, submission, time.Now(), func(ctx context.Context) {
+		h.runner.ProcessSubmissionWith(ctx, submission, reporter)
+	})
+}
+
+// handle chờ slot rồi gọi process với context của submission (bị hủy khi có yêu cầu hủy hoặc hết thời gian).
+func (h *JobHandler) handle(parent context.Context, submission models.Submission, queuedAt time.Time, process func(ctx context.Context)) {
+	// Context bị hủy khi có yêu cầu hủy submission, kể cả khi yêu cầu tới trước submission
+	// (nếu submission được đưa vào hàng đợi trước khi yêu cầu được gửi).
+	cancelCtx, release := h.cancels.register(parent, submission.ID, queuedAt)
+	defer release()
+	h.publishProgress(submission, models.StageReceived, 0)
+	if cancelCtx.Err() != nil {
+		// Đã bị hủy trước khi tới runner: Core Runner chỉ báo Cancelled cho các test case, không chạy gì.
+		log.Printf("JobHandler: SubmissionID %s was cancelled before it was received, dropping it.", submission.ID)
+		process(cancelCtx)
+		return
+	}
 	if h.jobSemaphore != nil {
 		log.Printf("JobHandler: Attempting to acquire semaphore for SubmissionID: %s. Current active jobs: %d/%d",
 			submission.ID, len(h.jobSemaphore), cap(h.jobSemaphore)) // Lưu ý: len(channel) là số item đang có, cap(channel) - len(channel) là số chỗ trống.
@@ -45,21 +100,141 @@ func (h *JobHandler) HandleSubmission(submission models.Submission) {
 		// Hoặc hiểu len(h.jobSemaphore) là số lượng "token" đã được dùng nếu channel đầy.
 		// Cách đơn giản hơn để hiểu: cap(h.jobSemaphore) là tổng slot, khi acquire thì 1 slot bị chiếm.
 		now := time.Now()
-		h.jobSemaphore <- struct{}{} // Acquire a slot.
-		log.Printf("JobHandler: Semaphore acquired for SubmissionID: %s. Time taken: %s", submission.ID, time.Since(now))
-		log.Printf("JobHandler: Semaphore acquired for SubmissionID: %s.", submission.ID)
+		select {
+		case h.jobSemaphore <- struct{}{}: // Acquire a slot ngay nếu còn chỗ trống.
+		default:
+			h.publishProgress(submission, models.StageWaiting, 0)
+			h.waiting.Add(1)
+			select {
+			case h.jobSemaphore <- struct{}{}: // Acquire a slot.
+				h.waiting.Add(-1)
+			case <-cancelCtx.Done():
+				h.waiting.Add(-1)
+				// Bị hủy khi còn đang chờ: bỏ qua mà không chiếm slot.
+				log.Printf("JobHandler: SubmissionID %s was cancelled while waiting for a slot, dropping it.", submission.ID)
+				process(cancelCtx)
+				return
+			}
+		}
+		queueWait := time.Since(now)
+		log.Printf("JobHandler: Semaphore acquired for SubmissionID: %s. Time taken: %s", submission.ID, queueWait)
+		h.publishProgress(submission, models.StageStarted, queueWait)
 		defer func() {
 			<-h.jobSemaphore // Release the slot khi xử lý xong
 			log.Printf("JobHandler: Semaphore released for SubmissionID: %s.", submission.ID)
 		}()
 	} else {
 		log.Printf("JobHandler: Processing SubmissionID: %s without concurrency limit.", submission.ID)
+		h.publishProgress(submission, models.StageStarted, 0)
 	}
 	log.Printf("JobHandler: Received SubmissionID: %s. Delegating to Core Runner.", submission.ID)
 	// Nên tạo context sau khi đã chiếm được slot từ semaphore nếu bạn muốn timeout chỉ áp dụng cho ProcessSubmission.
-	submissionCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute) // Timeout này từ code gốc
+	submissionCtx, cancel := context.WithTimeout(cancelCtx, 5*time.Minute) // Timeout này từ code gốc
 	defer cancel()
 
-	h.runner.ProcessSubmission(submissionCtx, submission)
+	h.running.Add(1)
+	defer h.running.Add(-1)
+	process(submissionCtx)
 	log.Printf("JobHandler: Core Runner finished processing SubmissionID: %s.", submission.ID)
 }
+
+// HandleRunRequest chấm submission của một request chạy code và trả về mọi kết quả.
+// Request dùng chung slot (maxConcurrentJobs) với submission chấm bất đồng bộ và có thể bị hủy qua
+// submission.cancel. Thời hạn tính cả thời gian chờ slot: hết hạn khi còn đang chờ thì submission không được
+// chạy và câu trả lời có Busy = true; hết hạn khi đang chạy thì phần chưa chạy được báo là Cancelled.
+// Submission cũng dừng khi ctx bị hủy (ví dụ client HTTP ngắt kết nối).
+func (h *JobHandler) HandleRunRequest(ctx context.Context, req models.RunSubmissionRequest) models.RunSubmissionResponse {
+	return h.runRequest(ctx, req, true)
+}
+
+// TryHandleRunRequest chấm request chạy code giống HandleRunRequest nhưng không chờ slot: khi mọi slot
+// đang bận, câu trả lời có Busy = true được trả về ngay.
+// This method signature matches the RunRequestHandler interface in the nats package.
+func (h *JobHandler) TryHandleRunRequest(ctx context.Context, req models.RunSubmissionRequest) models.RunSubmissionResponse {
+	return h.runRequest(ctx, req, false)
+}
+
+// runRequest chấm request chạy code; wait = false thì không chờ khi mọi slot đang bận.
+func (h *JobHandler) runRequest(ctx context.Context, req models.RunSubmissionRequest, wait bool) models.RunSubmissionResponse {
+	submission := req.Submission
+	timeout := defaultRunTimeout
+	if req.TimeoutInMs > 0 {
+		timeout = min(time.Duration(req.TimeoutInMs)*time.Millisecond, maxRunTimeout)
+	}
+	cancelCtx, release := h.cancels.register(ctx, submission.ID, time.Now())
+	defer release()
+	runCtx, cancel := context.WithTimeoutCause(cancelCtx, timeout,
+		fmt.Errorf("%w: run deadline of %s exceeded", core.ErrCancelled, timeout))
+	defer cancel()
+
+	if h.jobSemaphore != nil {
+		select {
+		case h.jobSemaphore <- struct{}{}:
+		default:
+			if !wait {
+				log.Printf("JobHandler: No free slot for run request of SubmissionID %s, replying busy.", submission.ID)
+				return busyResponse(submission.ID, fmt.Sprintf("runner is busy (%d/%d jobs running), retry the request",
+					len(h.jobSemaphore), cap(h.jobSemaphore)))
+			}
+			h.waiting.Add(1)
+			select {
+			case h.jobSemaphore <- struct{}{}:
+				h.waiting.Add(-1)
+			case <-runCtx.Done():
+				h.waiting.Add(-1)
+				// Không chạy khi không có slot, kể cả để báo Cancelled, để không vượt quá maxConcurrentJobs.
+				if cancelCtx.Err() != nil { // Bị hủy qua submission.cancel hoặc client đã ngắt kết nối
+					log.Printf("JobHandler: Run request for SubmissionID %s was cancelled while waiting for a slot.", submission.ID)
+					return models.RunSubmissionResponse{
+						SubmissionID: submission.ID,
+						Results:      []models.SubmissionResult{},
+						Error:        context.Cause(cancelCtx).Error(),
+					}
+				}
+				log.Printf("JobHandler: Run request for SubmissionID %s expired while waiting for a slot.", submission.ID)
+				return busyResponse(submission.ID, fmt.Sprintf("no free slot within the run deadline of %s, retry the request", timeout))
+			}
+		}
+		defer func() { <-h.jobSemaphore }()
+	}
+	h.running.Add(1)
+	defer h.running.Add(-1)
+	log.Printf("JobHandler: Running SubmissionID %s for a run request (deadline %s).", submission.ID, timeout)
+	return h.runner.RunSubmission(runCtx, submission)
+}
+
+// busyResponse là câu trả lời của request chạy code không được chạy vì không có slot trống.
+func busyResponse(submissionID, reason string) models.RunSubmissionResponse {
+	return models.RunSubmissionResponse{
+		SubmissionID: submissionID,
+		Results:      []models.SubmissionResult{},
+		Error:        reason,
+		Busy:         true,
+	}
+}
+
+// CancelSubmission hủy submission đang chờ slot hoặc đang chạy trên runner này: các tiến trình
+// trong sandbox (biên dịch, chạy, checker) bị kill và các test case còn lại được báo là Cancelled.
+// This method signature matches the SubmissionCanceller interface in the nats package.
+func (h *JobHandler) CancelSubmission(cancel models.SubmissionCancel) {
+	requestedAt := time.Now()
+	if cancel.RequestedAt > 0 {
+		requestedAt = time.UnixMilli(cancel.RequestedAt)
+	}
+	if h.cancels.cancel(cancel.SubmissionID, cancel.Reason, requestedAt) {
+		log.Printf("JobHandler: Cancelled SubmissionID %s (reason: %q).", cancel.SubmissionID, cancel.Reason)
+	} else {
+		log.Printf("JobHandler: SubmissionID %s is not running here; it will be dropped if received later and queued before %s.",
+			cancel.SubmissionID, requestedAt.Format(time.RFC3339Nano))
+	}
+}
+
+// publishProgress publish sự kiện tiến độ của giai đoạn trước khi runner bắt đầu xử lý submission.
+func (h *JobHandler) publishProgress(submission models.Submission, stage models.ProgressStage, queueWait time.Duration) {
+	h.natsPublisher.PublishSubmissionProgress(models.SubmissionProgress{
+		SubmissionID:   submission.ID,
+		Stage:          stage,
+		TotalTestCases: len(submission.TestCases),
+		QueueWaitInMs:  queueWait.Milliseconds(),
+	})
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Mirai3103/remote-compiler/internal/config"
+	"github.com/Mirai3103/remote-compiler/internal/models"
+)
+
+// TestTryHandleRunRequestBusy kiểm tra request chạy code từ NATS không chờ slot khi mọi slot đang bận.
+func TestTryHandleRunRequestBusy(t *testing.T) {
+	h := NewJobHandler(nil, nil, &config.RunnerConfig{MaxConcurrentJobs: 1})
+	h.jobSemaphore <- struct{}{} // Slot duy nhất đang bận
+
+	req := models.RunSubmissionRequest{Submission: models.Submission{ID: "run-1"}}
+	resp := h.TryHandleRunRequest(context.Background(), req)
+	if !resp.Busy || resp.Error == "" {
+		t.Errorf("TryHandleRunRequest() = %+v, want a busy response", resp)
+	}
+	if resp.SubmissionID != "run-1" || resp.Results == nil {
+		t.Errorf("busy response = %+v, want submissionId run-1 and empty results", resp)
+	}
+	if stats := h.Stats(); stats.Running != 0 || stats.Waiting != 0 {
+		t.Errorf("Stats() = %+v, want nothing running or waiting", stats)
+	}
+}
+
+// TestHandleRunRequestExpiresWithoutSlot kiểm tra request chạy code hết hạn khi đang chờ slot không được chạy
+// (không vượt quá maxConcurrentJobs) và được trả lời là busy.
+func TestHandleRunRequestExpiresWithoutSlot(t *testing.T) {
+	h := NewJobHandler(nil, nil, &config.RunnerConfig{MaxConcurrentJobs: 1})
+	h.jobSemaphore <- struct{}{}
+
+	req := models.RunSubmissionRequest{Submission: models.Submission{ID: "run-1"}, TimeoutInMs: 50}
+	resp := h.HandleRunRequest(context.Background(), req)
+	if !resp.Busy || resp.Error == "" {
+		t.Errorf("HandleRunRequest() = %+v, want a busy response", resp)
+	}
+	if stats := h.Stats(); stats.Running != 0 || stats.Waiting != 0 {
+		t.Errorf("Stats() = %+v, want nothing running or waiting", stats)
+	}
+	if len(h.jobSemaphore) != 1 {
+		t.Errorf("%d slots taken, want only the one held by the test", len(h.jobSemaphore))
+	}
+}
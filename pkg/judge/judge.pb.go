@@ -0,0 +1,1667 @@
+// Judge là gRPC API của runner, chạy song song với NATS (xem internal/grpcapi).
+// Các message tương ứng với internal/models; status/verdict/stage là chuỗi giống hệt JSON
+// ("success", "wrong_answer", "time_limit_exceeded", ...).
+//
+// Sinh lại pkg/judge (chạy ở thư mục gốc của repo, cần protoc-gen-go và protoc-gen-go-grpc):
+//
+//	protoc --go_out=. --go_opt=module=github.com/Mirai3103/remote-compiler \
+//	  --go-grpc_out=. --go-grpc_opt=module=github.com/Mirai3103/remote-compiler assets/judge.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: assets/judge.proto
+
+package judge
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Submission struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	LanguageId      string                 `protobuf:"bytes,2,opt,name=language_id,json=languageId,proto3" json:"language_id,omitempty"`
+	Code            string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	TimeLimitInMs   int32                  `protobuf:"varint,4,opt,name=time_limit_in_ms,json=timeLimitInMs,proto3" json:"time_limit_in_ms,omitempty"`
+	MemoryLimitInKb int32                  `protobuf:"varint,5,opt,name=memory_limit_in_kb,json=memoryLimitInKb,proto3" json:"memory_limit_in_kb,omitempty"`
+	TestCases       []*TestCase            `protobuf:"bytes,6,rep,name=test_cases,json=testCases,proto3" json:"test_cases,omitempty"`
+	Settings        *Settings              `protobuf:"bytes,7,opt,name=settings,proto3" json:"settings,omitempty"`
+	CompileFlags    []string               `protobuf:"bytes,8,rep,name=compile_flags,json=compileFlags,proto3" json:"compile_flags,omitempty"`
+	Checker         *Checker               `protobuf:"bytes,9,opt,name=checker,proto3" json:"checker,omitempty"`        // Special judge thay cho so sánh output
+	Interactor      *Checker               `protobuf:"bytes,10,opt,name=interactor,proto3" json:"interactor,omitempty"` // Bài tương tác; không dùng chung với checker
+	Subtasks        []*Subtask             `protobuf:"bytes,11,rep,name=subtasks,proto3" json:"subtasks,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Submission) Reset() {
+	*x = Submission{}
+	mi := &file_assets_judge_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Submission) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Submission) ProtoMessage() {}
+
+func (x *Submission) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Submission.ProtoReflect.Descriptor instead.
+func (*Submission) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Submission) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Submission) GetLanguageId() string {
+	if x != nil {
+		return x.LanguageId
+	}
+	return ""
+}
+
+func (x *Submission) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Submission) GetTimeLimitInMs() int32 {
+	if x != nil {
+		return x.TimeLimitInMs
+	}
+	return 0
+}
+
+func (x *Submission) GetMemoryLimitInKb() int32 {
+	if x != nil {
+		return x.MemoryLimitInKb
+	}
+	return 0
+}
+
+func (x *Submission) GetTestCases() []*TestCase {
+	if x != nil {
+		return x.TestCases
+	}
+	return nil
+}
+
+func (x *Submission) GetSettings() *Settings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+func (x *Submission) GetCompileFlags() []string {
+	if x != nil {
+		return x.CompileFlags
+	}
+	return nil
+}
+
+func (x *Submission) GetChecker() *Checker {
+	if x != nil {
+		return x.Checker
+	}
+	return nil
+}
+
+func (x *Submission) GetInteractor() *Checker {
+	if x != nil {
+		return x.Interactor
+	}
+	return nil
+}
+
+func (x *Submission) GetSubtasks() []*Subtask {
+	if x != nil {
+		return x.Subtasks
+	}
+	return nil
+}
+
+type TestCase struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Input         string                 `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
+	ExpectOutput  string                 `protobuf:"bytes,3,opt,name=expect_output,json=expectOutput,proto3" json:"expect_output,omitempty"`
+	Points        float64                `protobuf:"fixed64,4,opt,name=points,proto3" json:"points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestCase) Reset() {
+	*x = TestCase{}
+	mi := &file_assets_judge_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestCase) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestCase) ProtoMessage() {}
+
+func (x *TestCase) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestCase.ProtoReflect.Descriptor instead.
+func (*TestCase) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TestCase) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TestCase) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *TestCase) GetExpectOutput() string {
+	if x != nil {
+		return x.ExpectOutput
+	}
+	return ""
+}
+
+func (x *TestCase) GetPoints() float64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+type Settings struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	WithTrim           bool                   `protobuf:"varint,1,opt,name=with_trim,json=withTrim,proto3" json:"with_trim,omitempty"`
+	WithCaseSensitive  bool                   `protobuf:"varint,2,opt,name=with_case_sensitive,json=withCaseSensitive,proto3" json:"with_case_sensitive,omitempty"`
+	WithWhitespace     bool                   `protobuf:"varint,3,opt,name=with_whitespace,json=withWhitespace,proto3" json:"with_whitespace,omitempty"`
+	ComparisonMode     string                 `protobuf:"bytes,4,opt,name=comparison_mode,json=comparisonMode,proto3" json:"comparison_mode,omitempty"`
+	AbsoluteEpsilon    float64                `protobuf:"fixed64,5,opt,name=absolute_epsilon,json=absoluteEpsilon,proto3" json:"absolute_epsilon,omitempty"`
+	RelativeEpsilon    float64                `protobuf:"fixed64,6,opt,name=relative_epsilon,json=relativeEpsilon,proto3" json:"relative_epsilon,omitempty"`
+	StopOnFirstFailure string                 `protobuf:"bytes,7,opt,name=stop_on_first_failure,json=stopOnFirstFailure,proto3" json:"stop_on_first_failure,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Settings) Reset() {
+	*x = Settings{}
+	mi := &file_assets_judge_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Settings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Settings) ProtoMessage() {}
+
+func (x *Settings) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Settings.ProtoReflect.Descriptor instead.
+func (*Settings) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Settings) GetWithTrim() bool {
+	if x != nil {
+		return x.WithTrim
+	}
+	return false
+}
+
+func (x *Settings) GetWithCaseSensitive() bool {
+	if x != nil {
+		return x.WithCaseSensitive
+	}
+	return false
+}
+
+func (x *Settings) GetWithWhitespace() bool {
+	if x != nil {
+		return x.WithWhitespace
+	}
+	return false
+}
+
+func (x *Settings) GetComparisonMode() string {
+	if x != nil {
+		return x.ComparisonMode
+	}
+	return ""
+}
+
+func (x *Settings) GetAbsoluteEpsilon() float64 {
+	if x != nil {
+		return x.AbsoluteEpsilon
+	}
+	return 0
+}
+
+func (x *Settings) GetRelativeEpsilon() float64 {
+	if x != nil {
+		return x.RelativeEpsilon
+	}
+	return 0
+}
+
+func (x *Settings) GetStopOnFirstFailure() string {
+	if x != nil {
+		return x.StopOnFirstFailure
+	}
+	return ""
+}
+
+type Checker struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	LanguageId    string                 `protobuf:"bytes,2,opt,name=language_id,json=languageId,proto3" json:"language_id,omitempty"`
+	Code          string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Checker) Reset() {
+	*x = Checker{}
+	mi := &file_assets_judge_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Checker) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Checker) ProtoMessage() {}
+
+func (x *Checker) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Checker.ProtoReflect.Descriptor instead.
+func (*Checker) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Checker) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Checker) GetLanguageId() string {
+	if x != nil {
+		return x.LanguageId
+	}
+	return ""
+}
+
+func (x *Checker) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type Subtask struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	Scoring       string                 `protobuf:"bytes,3,opt,name=scoring,proto3" json:"scoring,omitempty"`
+	TestCaseIds   []string               `protobuf:"bytes,4,rep,name=test_case_ids,json=testCaseIds,proto3" json:"test_case_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subtask) Reset() {
+	*x = Subtask{}
+	mi := &file_assets_judge_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subtask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subtask) ProtoMessage() {}
+
+func (x *Subtask) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subtask.ProtoReflect.Descriptor instead.
+func (*Subtask) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Subtask) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Subtask) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Subtask) GetScoring() string {
+	if x != nil {
+		return x.Scoring
+	}
+	return ""
+}
+
+func (x *Subtask) GetTestCaseIds() []string {
+	if x != nil {
+		return x.TestCaseIds
+	}
+	return nil
+}
+
+type TestResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId   string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	TestCaseId     string                 `protobuf:"bytes,2,opt,name=test_case_id,json=testCaseId,proto3" json:"test_case_id,omitempty"`
+	Status         string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	TimeUsedInMs   int32                  `protobuf:"varint,4,opt,name=time_used_in_ms,json=timeUsedInMs,proto3" json:"time_used_in_ms,omitempty"`
+	MemoryUsedInKb int32                  `protobuf:"varint,5,opt,name=memory_used_in_kb,json=memoryUsedInKb,proto3" json:"memory_used_in_kb,omitempty"`
+	Output         string                 `protobuf:"bytes,6,opt,name=output,proto3" json:"output,omitempty"`
+	Error          string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	CompileCache   string                 `protobuf:"bytes,8,opt,name=compile_cache,json=compileCache,proto3" json:"compile_cache,omitempty"`
+	CheckerMessage string                 `protobuf:"bytes,9,opt,name=checker_message,json=checkerMessage,proto3" json:"checker_message,omitempty"`
+	Transcript     string                 `protobuf:"bytes,10,opt,name=transcript,proto3" json:"transcript,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TestResult) Reset() {
+	*x = TestResult{}
+	mi := &file_assets_judge_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestResult) ProtoMessage() {}
+
+func (x *TestResult) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestResult.ProtoReflect.Descriptor instead.
+func (*TestResult) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TestResult) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+func (x *TestResult) GetTestCaseId() string {
+	if x != nil {
+		return x.TestCaseId
+	}
+	return ""
+}
+
+func (x *TestResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *TestResult) GetTimeUsedInMs() int32 {
+	if x != nil {
+		return x.TimeUsedInMs
+	}
+	return 0
+}
+
+func (x *TestResult) GetMemoryUsedInKb() int32 {
+	if x != nil {
+		return x.MemoryUsedInKb
+	}
+	return 0
+}
+
+func (x *TestResult) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *TestResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *TestResult) GetCompileCache() string {
+	if x != nil {
+		return x.CompileCache
+	}
+	return ""
+}
+
+func (x *TestResult) GetCheckerMessage() string {
+	if x != nil {
+		return x.CheckerMessage
+	}
+	return ""
+}
+
+func (x *TestResult) GetTranscript() string {
+	if x != nil {
+		return x.Transcript
+	}
+	return ""
+}
+
+type SubtaskScore struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SubtaskId     string                 `protobuf:"bytes,1,opt,name=subtask_id,json=subtaskId,proto3" json:"subtask_id,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	MaxScore      float64                `protobuf:"fixed64,3,opt,name=max_score,json=maxScore,proto3" json:"max_score,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	Passed        int32                  `protobuf:"varint,5,opt,name=passed,proto3" json:"passed,omitempty"`
+	Total         int32                  `protobuf:"varint,6,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubtaskScore) Reset() {
+	*x = SubtaskScore{}
+	mi := &file_assets_judge_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubtaskScore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubtaskScore) ProtoMessage() {}
+
+func (x *SubtaskScore) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubtaskScore.ProtoReflect.Descriptor instead.
+func (*SubtaskScore) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SubtaskScore) GetSubtaskId() string {
+	if x != nil {
+		return x.SubtaskId
+	}
+	return ""
+}
+
+func (x *SubtaskScore) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *SubtaskScore) GetMaxScore() float64 {
+	if x != nil {
+		return x.MaxScore
+	}
+	return 0
+}
+
+func (x *SubtaskScore) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SubtaskScore) GetPassed() int32 {
+	if x != nil {
+		return x.Passed
+	}
+	return 0
+}
+
+func (x *SubtaskScore) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type Score struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId  string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	MaxScore      float64                `protobuf:"fixed64,3,opt,name=max_score,json=maxScore,proto3" json:"max_score,omitempty"`
+	Subtasks      []*SubtaskScore        `protobuf:"bytes,4,rep,name=subtasks,proto3" json:"subtasks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Score) Reset() {
+	*x = Score{}
+	mi := &file_assets_judge_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Score) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Score) ProtoMessage() {}
+
+func (x *Score) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Score.ProtoReflect.Descriptor instead.
+func (*Score) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Score) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+func (x *Score) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Score) GetMaxScore() float64 {
+	if x != nil {
+		return x.MaxScore
+	}
+	return 0
+}
+
+func (x *Score) GetSubtasks() []*SubtaskScore {
+	if x != nil {
+		return x.Subtasks
+	}
+	return nil
+}
+
+type Summary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId  string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	Verdict       string                 `protobuf:"bytes,2,opt,name=verdict,proto3" json:"verdict,omitempty"`
+	Passed        int32                  `protobuf:"varint,3,opt,name=passed,proto3" json:"passed,omitempty"`
+	Total         int32                  `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`
+	MaxTimeInMs   int32                  `protobuf:"varint,5,opt,name=max_time_in_ms,json=maxTimeInMs,proto3" json:"max_time_in_ms,omitempty"`
+	MaxMemoryInKb int32                  `protobuf:"varint,6,opt,name=max_memory_in_kb,json=maxMemoryInKb,proto3" json:"max_memory_in_kb,omitempty"`
+	CompileLog    string                 `protobuf:"bytes,7,opt,name=compile_log,json=compileLog,proto3" json:"compile_log,omitempty"`
+	CompileCache  string                 `protobuf:"bytes,8,opt,name=compile_cache,json=compileCache,proto3" json:"compile_cache,omitempty"`
+	DurationInMs  int64                  `protobuf:"varint,9,opt,name=duration_in_ms,json=durationInMs,proto3" json:"duration_in_ms,omitempty"`
+	Error         string                 `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Summary) Reset() {
+	*x = Summary{}
+	mi := &file_assets_judge_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Summary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Summary) ProtoMessage() {}
+
+func (x *Summary) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Summary.ProtoReflect.Descriptor instead.
+func (*Summary) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Summary) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+func (x *Summary) GetVerdict() string {
+	if x != nil {
+		return x.Verdict
+	}
+	return ""
+}
+
+func (x *Summary) GetPassed() int32 {
+	if x != nil {
+		return x.Passed
+	}
+	return 0
+}
+
+func (x *Summary) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Summary) GetMaxTimeInMs() int32 {
+	if x != nil {
+		return x.MaxTimeInMs
+	}
+	return 0
+}
+
+func (x *Summary) GetMaxMemoryInKb() int32 {
+	if x != nil {
+		return x.MaxMemoryInKb
+	}
+	return 0
+}
+
+func (x *Summary) GetCompileLog() string {
+	if x != nil {
+		return x.CompileLog
+	}
+	return ""
+}
+
+func (x *Summary) GetCompileCache() string {
+	if x != nil {
+		return x.CompileCache
+	}
+	return ""
+}
+
+func (x *Summary) GetDurationInMs() int64 {
+	if x != nil {
+		return x.DurationInMs
+	}
+	return 0
+}
+
+func (x *Summary) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type Progress struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId   string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	Stage          string                 `protobuf:"bytes,2,opt,name=stage,proto3" json:"stage,omitempty"`
+	Status         string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	TestCaseId     string                 `protobuf:"bytes,4,opt,name=test_case_id,json=testCaseId,proto3" json:"test_case_id,omitempty"`
+	TestCaseIndex  int32                  `protobuf:"varint,5,opt,name=test_case_index,json=testCaseIndex,proto3" json:"test_case_index,omitempty"`
+	TotalTestCases int32                  `protobuf:"varint,6,opt,name=total_test_cases,json=totalTestCases,proto3" json:"total_test_cases,omitempty"`
+	QueueWaitInMs  int64                  `protobuf:"varint,7,opt,name=queue_wait_in_ms,json=queueWaitInMs,proto3" json:"queue_wait_in_ms,omitempty"`
+	Timestamp      int64                  `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix milliseconds
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_assets_judge_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Progress) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+func (x *Progress) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *Progress) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Progress) GetTestCaseId() string {
+	if x != nil {
+		return x.TestCaseId
+	}
+	return ""
+}
+
+func (x *Progress) GetTestCaseIndex() int32 {
+	if x != nil {
+		return x.TestCaseIndex
+	}
+	return 0
+}
+
+func (x *Progress) GetTotalTestCases() int32 {
+	if x != nil {
+		return x.TotalTestCases
+	}
+	return 0
+}
+
+func (x *Progress) GetQueueWaitInMs() int64 {
+	if x != nil {
+		return x.QueueWaitInMs
+	}
+	return 0
+}
+
+func (x *Progress) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type SubmitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Submission    *Submission            `protobuf:"bytes,1,opt,name=submission,proto3" json:"submission,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitRequest) Reset() {
+	*x = SubmitRequest{}
+	mi := &file_assets_judge_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitRequest) ProtoMessage() {}
+
+func (x *SubmitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitRequest.ProtoReflect.Descriptor instead.
+func (*SubmitRequest) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SubmitRequest) GetSubmission() *Submission {
+	if x != nil {
+		return x.Submission
+	}
+	return nil
+}
+
+type SubmitEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*SubmitEvent_Progress
+	//	*SubmitEvent_Result
+	//	*SubmitEvent_Score
+	//	*SubmitEvent_Summary
+	Event         isSubmitEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitEvent) Reset() {
+	*x = SubmitEvent{}
+	mi := &file_assets_judge_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitEvent) ProtoMessage() {}
+
+func (x *SubmitEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitEvent.ProtoReflect.Descriptor instead.
+func (*SubmitEvent) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SubmitEvent) GetEvent() isSubmitEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *SubmitEvent) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*SubmitEvent_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *SubmitEvent) GetResult() *TestResult {
+	if x != nil {
+		if x, ok := x.Event.(*SubmitEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *SubmitEvent) GetScore() *Score {
+	if x != nil {
+		if x, ok := x.Event.(*SubmitEvent_Score); ok {
+			return x.Score
+		}
+	}
+	return nil
+}
+
+func (x *SubmitEvent) GetSummary() *Summary {
+	if x != nil {
+		if x, ok := x.Event.(*SubmitEvent_Summary); ok {
+			return x.Summary
+		}
+	}
+	return nil
+}
+
+type isSubmitEvent_Event interface {
+	isSubmitEvent_Event()
+}
+
+type SubmitEvent_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type SubmitEvent_Result struct {
+	Result *TestResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+type SubmitEvent_Score struct {
+	Score *Score `protobuf:"bytes,3,opt,name=score,proto3,oneof"`
+}
+
+type SubmitEvent_Summary struct {
+	Summary *Summary `protobuf:"bytes,4,opt,name=summary,proto3,oneof"` // Luôn là sự kiện cuối cùng
+}
+
+func (*SubmitEvent_Progress) isSubmitEvent_Event() {}
+
+func (*SubmitEvent_Result) isSubmitEvent_Event() {}
+
+func (*SubmitEvent_Score) isSubmitEvent_Event() {}
+
+func (*SubmitEvent_Summary) isSubmitEvent_Event() {}
+
+type RunRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Submission *Submission            `protobuf:"bytes,1,opt,name=submission,proto3" json:"submission,omitempty"`
+	// Thời hạn, tính cả thời gian chờ slot; hết hạn thì các test case chưa xong là "cancelled".
+	// 0 = mặc định của runner (30 giây), tối đa 5 phút.
+	TimeoutInMs   int32 `protobuf:"varint,2,opt,name=timeout_in_ms,json=timeoutInMs,proto3" json:"timeout_in_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunRequest) Reset() {
+	*x = RunRequest{}
+	mi := &file_assets_judge_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunRequest) ProtoMessage() {}
+
+func (x *RunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunRequest.ProtoReflect.Descriptor instead.
+func (*RunRequest) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RunRequest) GetSubmission() *Submission {
+	if x != nil {
+		return x.Submission
+	}
+	return nil
+}
+
+func (x *RunRequest) GetTimeoutInMs() int32 {
+	if x != nil {
+		return x.TimeoutInMs
+	}
+	return 0
+}
+
+type RunResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Summary       *Summary               `protobuf:"bytes,1,opt,name=summary,proto3" json:"summary,omitempty"`
+	Results       []*TestResult          `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	Score         *Score                 `protobuf:"bytes,3,opt,name=score,proto3" json:"score,omitempty"` // Chỉ có khi submission có subtask
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunResponse) Reset() {
+	*x = RunResponse{}
+	mi := &file_assets_judge_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunResponse) ProtoMessage() {}
+
+func (x *RunResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunResponse.ProtoReflect.Descriptor instead.
+func (*RunResponse) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RunResponse) GetSummary() *Summary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *RunResponse) GetResults() []*TestResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *RunResponse) GetScore() *Score {
+	if x != nil {
+		return x.Score
+	}
+	return nil
+}
+
+type CancelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SubmissionId  string                 `protobuf:"bytes,1,opt,name=submission_id,json=submissionId,proto3" json:"submission_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelRequest) Reset() {
+	*x = CancelRequest{}
+	mi := &file_assets_judge_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRequest) ProtoMessage() {}
+
+func (x *CancelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRequest.ProtoReflect.Descriptor instead.
+func (*CancelRequest) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CancelRequest) GetSubmissionId() string {
+	if x != nil {
+		return x.SubmissionId
+	}
+	return ""
+}
+
+func (x *CancelRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type CancelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelResponse) Reset() {
+	*x = CancelResponse{}
+	mi := &file_assets_judge_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelResponse) ProtoMessage() {}
+
+func (x *CancelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelResponse.ProtoReflect.Descriptor instead.
+func (*CancelResponse) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{15}
+}
+
+type ListLanguagesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLanguagesRequest) Reset() {
+	*x = ListLanguagesRequest{}
+	mi := &file_assets_judge_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLanguagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLanguagesRequest) ProtoMessage() {}
+
+func (x *ListLanguagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLanguagesRequest.ProtoReflect.Descriptor instead.
+func (*ListLanguagesRequest) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{16}
+}
+
+type Language struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Compiled      bool                   `protobuf:"varint,3,opt,name=compiled,proto3" json:"compiled,omitempty"`
+	AllowedFlags  []string               `protobuf:"bytes,4,rep,name=allowed_flags,json=allowedFlags,proto3" json:"allowed_flags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Language) Reset() {
+	*x = Language{}
+	mi := &file_assets_judge_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Language) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Language) ProtoMessage() {}
+
+func (x *Language) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Language.ProtoReflect.Descriptor instead.
+func (*Language) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Language) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Language) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Language) GetCompiled() bool {
+	if x != nil {
+		return x.Compiled
+	}
+	return false
+}
+
+func (x *Language) GetAllowedFlags() []string {
+	if x != nil {
+		return x.AllowedFlags
+	}
+	return nil
+}
+
+type ListLanguagesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Languages     []*Language            `protobuf:"bytes,1,rep,name=languages,proto3" json:"languages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLanguagesResponse) Reset() {
+	*x = ListLanguagesResponse{}
+	mi := &file_assets_judge_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLanguagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLanguagesResponse) ProtoMessage() {}
+
+func (x *ListLanguagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_assets_judge_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLanguagesResponse.ProtoReflect.Descriptor instead.
+func (*ListLanguagesResponse) Descriptor() ([]byte, []int) {
+	return file_assets_judge_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListLanguagesResponse) GetLanguages() []*Language {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+var File_assets_judge_proto protoreflect.FileDescriptor
+
+const file_assets_judge_proto_rawDesc = "" +
+	"\n" +
+	"\x12assets/judge.proto\x12\x05judge\"\xaf\x03\n" +
+	"\n" +
+	"Submission\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vlanguage_id\x18\x02 \x01(\tR\n" +
+	"languageId\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12'\n" +
+	"\x10time_limit_in_ms\x18\x04 \x01(\x05R\rtimeLimitInMs\x12+\n" +
+	"\x12memory_limit_in_kb\x18\x05 \x01(\x05R\x0fmemoryLimitInKb\x12.\n" +
+	"\n" +
+	"test_cases\x18\x06 \x03(\v2\x0f.judge.TestCaseR\ttestCases\x12+\n" +
+	"\bsettings\x18\a \x01(\v2\x0f.judge.SettingsR\bsettings\x12#\n" +
+	"\rcompile_flags\x18\b \x03(\tR\fcompileFlags\x12(\n" +
+	"\achecker\x18\t \x01(\v2\x0e.judge.CheckerR\achecker\x12.\n" +
+	"\n" +
+	"interactor\x18\n" +
+	" \x01(\v2\x0e.judge.CheckerR\n" +
+	"interactor\x12*\n" +
+	"\bsubtasks\x18\v \x03(\v2\x0e.judge.SubtaskR\bsubtasks\"m\n" +
+	"\bTestCase\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05input\x18\x02 \x01(\tR\x05input\x12#\n" +
+	"\rexpect_output\x18\x03 \x01(\tR\fexpectOutput\x12\x16\n" +
+	"\x06points\x18\x04 \x01(\x01R\x06points\"\xb2\x02\n" +
+	"\bSettings\x12\x1b\n" +
+	"\twith_trim\x18\x01 \x01(\bR\bwithTrim\x12.\n" +
+	"\x13with_case_sensitive\x18\x02 \x01(\bR\x11withCaseSensitive\x12'\n" +
+	"\x0fwith_whitespace\x18\x03 \x01(\bR\x0ewithWhitespace\x12'\n" +
+	"\x0fcomparison_mode\x18\x04 \x01(\tR\x0ecomparisonMode\x12)\n" +
+	"\x10absolute_epsilon\x18\x05 \x01(\x01R\x0fabsoluteEpsilon\x12)\n" +
+	"\x10relative_epsilon\x18\x06 \x01(\x01R\x0frelativeEpsilon\x121\n" +
+	"\x15stop_on_first_failure\x18\a \x01(\tR\x12stopOnFirstFailure\"N\n" +
+	"\aChecker\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vlanguage_id\x18\x02 \x01(\tR\n" +
+	"languageId\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\"m\n" +
+	"\aSubtask\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x12\x18\n" +
+	"\ascoring\x18\x03 \x01(\tR\ascoring\x12\"\n" +
+	"\rtest_case_ids\x18\x04 \x03(\tR\vtestCaseIds\"\xd9\x02\n" +
+	"\n" +
+	"TestResult\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12 \n" +
+	"\ftest_case_id\x18\x02 \x01(\tR\n" +
+	"testCaseId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12%\n" +
+	"\x0ftime_used_in_ms\x18\x04 \x01(\x05R\ftimeUsedInMs\x12)\n" +
+	"\x11memory_used_in_kb\x18\x05 \x01(\x05R\x0ememoryUsedInKb\x12\x16\n" +
+	"\x06output\x18\x06 \x01(\tR\x06output\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12#\n" +
+	"\rcompile_cache\x18\b \x01(\tR\fcompileCache\x12'\n" +
+	"\x0fchecker_message\x18\t \x01(\tR\x0echeckerMessage\x12\x1e\n" +
+	"\n" +
+	"transcript\x18\n" +
+	" \x01(\tR\n" +
+	"transcript\"\xa6\x01\n" +
+	"\fSubtaskScore\x12\x1d\n" +
+	"\n" +
+	"subtask_id\x18\x01 \x01(\tR\tsubtaskId\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x12\x1b\n" +
+	"\tmax_score\x18\x03 \x01(\x01R\bmaxScore\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x16\n" +
+	"\x06passed\x18\x05 \x01(\x05R\x06passed\x12\x14\n" +
+	"\x05total\x18\x06 \x01(\x05R\x05total\"\x90\x01\n" +
+	"\x05Score\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x12\x1b\n" +
+	"\tmax_score\x18\x03 \x01(\x01R\bmaxScore\x12/\n" +
+	"\bsubtasks\x18\x04 \x03(\v2\x13.judge.SubtaskScoreR\bsubtasks\"\xc6\x02\n" +
+	"\aSummary\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12\x18\n" +
+	"\averdict\x18\x02 \x01(\tR\averdict\x12\x16\n" +
+	"\x06passed\x18\x03 \x01(\x05R\x06passed\x12\x14\n" +
+	"\x05total\x18\x04 \x01(\x05R\x05total\x12#\n" +
+	"\x0emax_time_in_ms\x18\x05 \x01(\x05R\vmaxTimeInMs\x12'\n" +
+	"\x10max_memory_in_kb\x18\x06 \x01(\x05R\rmaxMemoryInKb\x12\x1f\n" +
+	"\vcompile_log\x18\a \x01(\tR\n" +
+	"compileLog\x12#\n" +
+	"\rcompile_cache\x18\b \x01(\tR\fcompileCache\x12$\n" +
+	"\x0eduration_in_ms\x18\t \x01(\x03R\fdurationInMs\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\"\x98\x02\n" +
+	"\bProgress\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12\x14\n" +
+	"\x05stage\x18\x02 \x01(\tR\x05stage\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12 \n" +
+	"\ftest_case_id\x18\x04 \x01(\tR\n" +
+	"testCaseId\x12&\n" +
+	"\x0ftest_case_index\x18\x05 \x01(\x05R\rtestCaseIndex\x12(\n" +
+	"\x10total_test_cases\x18\x06 \x01(\x05R\x0etotalTestCases\x12'\n" +
+	"\x10queue_wait_in_ms\x18\a \x01(\x03R\rqueueWaitInMs\x12\x1c\n" +
+	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\"B\n" +
+	"\rSubmitRequest\x121\n" +
+	"\n" +
+	"submission\x18\x01 \x01(\v2\x11.judge.SubmissionR\n" +
+	"submission\"\xc4\x01\n" +
+	"\vSubmitEvent\x12-\n" +
+	"\bprogress\x18\x01 \x01(\v2\x0f.judge.ProgressH\x00R\bprogress\x12+\n" +
+	"\x06result\x18\x02 \x01(\v2\x11.judge.TestResultH\x00R\x06result\x12$\n" +
+	"\x05score\x18\x03 \x01(\v2\f.judge.ScoreH\x00R\x05score\x12*\n" +
+	"\asummary\x18\x04 \x01(\v2\x0e.judge.SummaryH\x00R\asummaryB\a\n" +
+	"\x05event\"c\n" +
+	"\n" +
+	"RunRequest\x121\n" +
+	"\n" +
+	"submission\x18\x01 \x01(\v2\x11.judge.SubmissionR\n" +
+	"submission\x12\"\n" +
+	"\rtimeout_in_ms\x18\x02 \x01(\x05R\vtimeoutInMs\"\x88\x01\n" +
+	"\vRunResponse\x12(\n" +
+	"\asummary\x18\x01 \x01(\v2\x0e.judge.SummaryR\asummary\x12+\n" +
+	"\aresults\x18\x02 \x03(\v2\x11.judge.TestResultR\aresults\x12\"\n" +
+	"\x05score\x18\x03 \x01(\v2\f.judge.ScoreR\x05score\"L\n" +
+	"\rCancelRequest\x12#\n" +
+	"\rsubmission_id\x18\x01 \x01(\tR\fsubmissionId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\x10\n" +
+	"\x0eCancelResponse\"\x16\n" +
+	"\x14ListLanguagesRequest\"o\n" +
+	"\bLanguage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bcompiled\x18\x03 \x01(\bR\bcompiled\x12#\n" +
+	"\rallowed_flags\x18\x04 \x03(\tR\fallowedFlags\"F\n" +
+	"\x15ListLanguagesResponse\x12-\n" +
+	"\tlanguages\x18\x01 \x03(\v2\x0f.judge.LanguageR\tlanguages2\xee\x01\n" +
+	"\x05Judge\x124\n" +
+	"\x06Submit\x12\x14.judge.SubmitRequest\x1a\x12.judge.SubmitEvent0\x01\x12,\n" +
+	"\x03Run\x12\x11.judge.RunRequest\x1a\x12.judge.RunResponse\x125\n" +
+	"\x06Cancel\x12\x14.judge.CancelRequest\x1a\x15.judge.CancelResponse\x12J\n" +
+	"\rListLanguages\x12\x1b.judge.ListLanguagesRequest\x1a\x1c.judge.ListLanguagesResponseB0Z.github.com/Mirai3103/remote-compiler/pkg/judgeb\x06proto3"
+
+var (
+	file_assets_judge_proto_rawDescOnce sync.Once
+	file_assets_judge_proto_rawDescData []byte
+)
+
+func file_assets_judge_proto_rawDescGZIP() []byte {
+	file_assets_judge_proto_rawDescOnce.Do(func() {
+		file_assets_judge_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_assets_judge_proto_rawDesc), len(file_assets_judge_proto_rawDesc)))
+	})
+	return file_assets_judge_proto_rawDescData
+}
+
+var file_assets_judge_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_assets_judge_proto_goTypes = []any{
+	(*Submission)(nil),            // 0: judge.Submission
+	(*TestCase)(nil),              // 1: judge.TestCase
+	(*Settings)(nil),              // 2: judge.Settings
+	(*Checker)(nil),               // 3: judge.Checker
+	(*Subtask)(nil),               // 4: judge.Subtask
+	(*TestResult)(nil),            // 5: judge.TestResult
+	(*SubtaskScore)(nil),          // 6: judge.SubtaskScore
+	(*Score)(nil),                 // 7: judge.Score
+	(*Summary)(nil),               // 8: judge.Summary
+	(*Progress)(nil),              // 9: judge.Progress
+	(*SubmitRequest)(nil),         // 10: judge.SubmitRequest
+	(*SubmitEvent)(nil),           // 11: judge.SubmitEvent
+	(*RunRequest)(nil),            // 12: judge.RunRequest
+	(*RunResponse)(nil),           // 13: judge.RunResponse
+	(*CancelRequest)(nil),         // 14: judge.CancelRequest
+	(*CancelResponse)(nil),        // 15: judge.CancelResponse
+	(*ListLanguagesRequest)(nil),  // 16: judge.ListLanguagesRequest
+	(*Language)(nil),              // 17: judge.Language
+	(*ListLanguagesResponse)(nil), // 18: judge.ListLanguagesResponse
+}
+var file_assets_judge_proto_depIdxs = []int32{
+	1,  // 0: judge.Submission.test_cases:type_name -> judge.TestCase
+	2,  // 1: judge.Submission.settings:type_name -> judge.Settings
+	3,  // 2: judge.Submission.checker:type_name -> judge.Checker
+	3,  // 3: judge.Submission.interactor:type_name -> judge.Checker
+	4,  // 4: judge.Submission.subtasks:type_name -> judge.Subtask
+	6,  // 5: judge.Score.subtasks:type_name -> judge.SubtaskScore
+	0,  // 6: judge.SubmitRequest.submission:type_name -> judge.Submission
+	9,  // 7: judge.SubmitEvent.progress:type_name -> judge.Progress
+	5,  // 8: judge.SubmitEvent.result:type_name -> judge.TestResult
+	7,  // 9: judge.SubmitEvent.score:type_name -> judge.Score
+	8,  // 10: judge.SubmitEvent.summary:type_name -> judge.Summary
+	0,  // 11: judge.RunRequest.submission:type_name -> judge.Submission
+	8,  // 12: judge.RunResponse.summary:type_name -> judge.Summary
+	5,  // 13: judge.RunResponse.results:type_name -> judge.TestResult
+	7,  // 14: judge.RunResponse.score:type_name -> judge.Score
+	17, // 15: judge.ListLanguagesResponse.languages:type_name -> judge.Language
+	10, // 16: judge.Judge.Submit:input_type -> judge.SubmitRequest
+	12, // 17: judge.Judge.Run:input_type -> judge.RunRequest
+	14, // 18: judge.Judge.Cancel:input_type -> judge.CancelRequest
+	16, // 19: judge.Judge.ListLanguages:input_type -> judge.ListLanguagesRequest
+	11, // 20: judge.Judge.Submit:output_type -> judge.SubmitEvent
+	13, // 21: judge.Judge.Run:output_type -> judge.RunResponse
+	15, // 22: judge.Judge.Cancel:output_type -> judge.CancelResponse
+	18, // 23: judge.Judge.ListLanguages:output_type -> judge.ListLanguagesResponse
+	20, // [20:24] is the sub-list for method output_type
+	16, // [16:20] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_assets_judge_proto_init() }
+func file_assets_judge_proto_init() {
+	if File_assets_judge_proto != nil {
+		return
+	}
+	file_assets_judge_proto_msgTypes[11].OneofWrappers = []any{
+		(*SubmitEvent_Progress)(nil),
+		(*SubmitEvent_Result)(nil),
+		(*SubmitEvent_Score)(nil),
+		(*SubmitEvent_Summary)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_assets_judge_proto_rawDesc), len(file_assets_judge_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_assets_judge_proto_goTypes,
+		DependencyIndexes: file_assets_judge_proto_depIdxs,
+		MessageInfos:      file_assets_judge_proto_msgTypes,
+	}.Build()
+	File_assets_judge_proto = out.File
+	file_assets_judge_proto_goTypes = nil
+	file_assets_judge_proto_depIdxs = nil
+}
@@ -0,0 +1,258 @@
+// Judge là gRPC API của runner, chạy song song với NATS (xem internal/grpcapi).
+// Các message tương ứng với internal/models; status/verdict/stage là chuỗi giống hệt JSON
+// ("success", "wrong_answer", "time_limit_exceeded", ...).
+//
+// Sinh lại pkg/judge (chạy ở thư mục gốc của repo, cần protoc-gen-go và protoc-gen-go-grpc):
+//
+//	protoc --go_out=. --go_opt=module=github.com/Mirai3103/remote-compiler \
+//	  --go-grpc_out=. --go-grpc_opt=module=github.com/Mirai3103/remote-compiler assets/judge.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: assets/judge.proto
+
+package judge
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Judge_Submit_FullMethodName        = "/judge.Judge/Submit"
+	Judge_Run_FullMethodName           = "/judge.Judge/Run"
+	Judge_Cancel_FullMethodName        = "/judge.Judge/Cancel"
+	Judge_ListLanguages_FullMethodName = "/judge.Judge/ListLanguages"
+)
+
+// JudgeClient is the client API for Judge service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type JudgeClient interface {
+	// Submit chấm submission và stream sự kiện tiến độ, kết quả từng test case, điểm (nếu có subtask)
+	// và cuối cùng là summary. Hủy RPC sẽ dừng submission.
+	Submit(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubmitEvent], error)
+	// Run chấm submission và trả về mọi kết quả một lần, dùng cho nút "Run code" với input tự nhập.
+	Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error)
+	// Cancel gửi yêu cầu hủy lên NATS (submission.cancel), để runner đang giữ submission dừng nó.
+	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error)
+	// ListLanguages trả về các ngôn ngữ runner hỗ trợ.
+	ListLanguages(ctx context.Context, in *ListLanguagesRequest, opts ...grpc.CallOption) (*ListLanguagesResponse, error)
+}
+
+type judgeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJudgeClient(cc grpc.ClientConnInterface) JudgeClient {
+	return &judgeClient{cc}
+}
+
+func (c *judgeClient) Submit(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubmitEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Judge_ServiceDesc.Streams[0], Judge_Submit_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubmitRequest, SubmitEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Judge_SubmitClient = grpc.ServerStreamingClient[SubmitEvent]
+
+func (c *judgeClient) Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunResponse)
+	err := c.cc.Invoke(ctx, Judge_Run_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *judgeClient) Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelResponse)
+	err := c.cc.Invoke(ctx, Judge_Cancel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *judgeClient) ListLanguages(ctx context.Context, in *ListLanguagesRequest, opts ...grpc.CallOption) (*ListLanguagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLanguagesResponse)
+	err := c.cc.Invoke(ctx, Judge_ListLanguages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// JudgeServer is the server API for Judge service.
+// All implementations must embed UnimplementedJudgeServer
+// for forward compatibility.
+type JudgeServer interface {
+	// Submit chấm submission và stream sự kiện tiến độ, kết quả từng test case, điểm (nếu có subtask)
+	// và cuối cùng là summary. Hủy RPC sẽ dừng submission.
+	Submit(*SubmitRequest, grpc.ServerStreamingServer[SubmitEvent]) error
+	// Run chấm submission và trả về mọi kết quả một lần, dùng cho nút "Run code" với input tự nhập.
+	Run(context.Context, *RunRequest) (*RunResponse, error)
+	// Cancel gửi yêu cầu hủy lên NATS (submission.cancel), để runner đang giữ submission dừng nó.
+	Cancel(context.Context, *CancelRequest) (*CancelResponse, error)
+	// ListLanguages trả về các ngôn ngữ runner hỗ trợ.
+	ListLanguages(context.Context, *ListLanguagesRequest) (*ListLanguagesResponse, error)
+	mustEmbedUnimplementedJudgeServer()
+}
+
+// UnimplementedJudgeServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedJudgeServer struct{}
+
+func (UnimplementedJudgeServer) Submit(*SubmitRequest, grpc.ServerStreamingServer[SubmitEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Submit not implemented")
+}
+func (UnimplementedJudgeServer) Run(context.Context, *RunRequest) (*RunResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Run not implemented")
+}
+func (UnimplementedJudgeServer) Cancel(context.Context, *CancelRequest) (*CancelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cancel not implemented")
+}
+func (UnimplementedJudgeServer) ListLanguages(context.Context, *ListLanguagesRequest) (*ListLanguagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLanguages not implemented")
+}
+func (UnimplementedJudgeServer) mustEmbedUnimplementedJudgeServer() {}
+func (UnimplementedJudgeServer) testEmbeddedByValue()               {}
+
+// UnsafeJudgeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JudgeServer will
+// result in compilation errors.
+type UnsafeJudgeServer interface {
+	mustEmbedUnimplementedJudgeServer()
+}
+
+func RegisterJudgeServer(s grpc.ServiceRegistrar, srv JudgeServer) {
+	// If the following call pancis, it indicates UnimplementedJudgeServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Judge_ServiceDesc, srv)
+}
+
+func _Judge_Submit_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubmitRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JudgeServer).Submit(m, &grpc.GenericServerStream[SubmitRequest, SubmitEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Judge_SubmitServer = grpc.ServerStreamingServer[SubmitEvent]
+
+func _Judge_Run_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JudgeServer).Run(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Judge_Run_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JudgeServer).Run(ctx, req.(*RunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Judge_Cancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JudgeServer).Cancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Judge_Cancel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JudgeServer).Cancel(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Judge_ListLanguages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLanguagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JudgeServer).ListLanguages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Judge_ListLanguages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JudgeServer).ListLanguages(ctx, req.(*ListLanguagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Judge_ServiceDesc is the grpc.ServiceDesc for Judge service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Judge_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "judge.Judge",
+	HandlerType: (*JudgeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Run",
+			Handler:    _Judge_Run_Handler,
+		},
+		{
+			MethodName: "Cancel",
+			Handler:    _Judge_Cancel_Handler,
+		},
+		{
+			MethodName: "ListLanguages",
+			Handler:    _Judge_ListLanguages_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Submit",
+			Handler:       _Judge_Submit_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "assets/judge.proto",
+}